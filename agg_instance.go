@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	// aggHeartbeatEvery is how often an instance reports it is alive and
+	// extends the claims on the feeds it is fetching.
+	aggHeartbeatEvery = 15 * time.Second
+	// aggInstanceTTL is how long an instance may go without a heartbeat
+	// before others treat it as crashed and its claims as released.
+	aggInstanceTTL = time.Minute
+	// aggClaimTTL bounds how long a claimed feed stays off-limits to other
+	// instances without being extended by a heartbeat.
+	aggClaimTTL = 2 * time.Minute
+
+	leaderLease = "agg-leader"
+)
+
+// aggInstance is one running agg process. Instances share the feed rotation
+// by claiming feeds row by row, and elect a leader through the leases table
+// for work that must only run once across the cluster. One-off runs such as
+// agg --once and fetch register too, so they honour claims, but never lead.
+type aggInstance struct {
+	id       uuid.UUID
+	hostname string
+	db       *database.Queries
+	canLead  bool
+	leader   atomic.Bool
+	// cancel stops the heartbeat loop, which closes done when it returns.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startAggInstance(ctx context.Context, db *database.Queries, canLead bool) (*aggInstance, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	row, err := db.RegisterInstance(ctx, database.RegisterInstanceParams{
+		ID:       uuid.New(),
+		Hostname: hostname,
+		Pid:      int32(os.Getpid()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	inst := &aggInstance{id: row.ID, hostname: hostname, db: db, canLead: canLead, done: make(chan struct{})}
+	slog.Info("registered agg instance", "instance_id", inst.id, "hostname", hostname)
+	inst.heartbeat(ctx)
+	ctx, inst.cancel = context.WithCancel(ctx)
+	go inst.heartbeatLoop(ctx)
+	return inst, nil
+}
+
+func (inst *aggInstance) nullID() uuid.NullUUID {
+	return uuid.NullUUID{UUID: inst.id, Valid: true}
+}
+
+func (inst *aggInstance) isLeader() bool {
+	return inst.leader.Load()
+}
+
+func (inst *aggInstance) heartbeatLoop(ctx context.Context) {
+	defer close(inst.done)
+	ticker := time.NewTicker(aggHeartbeatEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			inst.heartbeat(ctx)
+		}
+	}
+}
+
+func (inst *aggInstance) heartbeat(ctx context.Context) {
+	if err := inst.db.HeartbeatInstance(ctx, database.HeartbeatInstanceParams{
+		ID:       inst.id,
+		Hostname: inst.hostname,
+		Pid:      int32(os.Getpid()),
+	}); err != nil {
+		slog.Error("couldn't record heartbeat", "instance_id", inst.id, "err", err)
+	}
+	if err := inst.db.ExtendFeedClaims(ctx, database.ExtendFeedClaimsParams{
+		ClaimSeconds: aggClaimTTL.Seconds(),
+		InstanceID:   inst.nullID(),
+	}); err != nil {
+		slog.Error("couldn't extend feed claims", "instance_id", inst.id, "err", err)
+	}
+	if n, err := inst.db.DeleteStaleInstances(ctx, aggInstanceTTL.Seconds()); err != nil {
+		slog.Error("couldn't remove stale instances", "err", err)
+	} else if n > 0 {
+		slog.Warn("removed crashed agg instances", "count", n)
+	}
+	if !inst.canLead {
+		return
+	}
+
+	_, err := inst.db.AcquireLease(ctx, database.AcquireLeaseParams{
+		Name:       leaderLease,
+		Holder:     inst.id,
+		TtlSeconds: aggInstanceTTL.Seconds(),
+	})
+	isLeader := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("couldn't acquire leader lease", "err", err)
+	}
+	if inst.leader.Swap(isLeader) != isLeader {
+		slog.Info("leadership changed", "instance_id", inst.id, "leader", isLeader)
+	}
+}
+
+// claimNextFeed claims the feed that has gone longest without a fetch and
+// that no other live instance is working on.
+func (inst *aggInstance) claimNextFeed(ctx context.Context) (database.Feed, error) {
+	return inst.db.ClaimNextFeedToFetch(ctx, database.ClaimNextFeedToFetchParams{
+		InstanceID:   inst.nullID(),
+		ClaimSeconds: aggClaimTTL.Seconds(),
+	})
+}
+
+// claimFeed claims one feed, whatever its status, unless another live
+// instance is working on it, in which case it returns sql.ErrNoRows.
+func (inst *aggInstance) claimFeed(ctx context.Context, feedID uuid.UUID) (database.Feed, error) {
+	return inst.db.ClaimFeed(ctx, database.ClaimFeedParams{
+		InstanceID:   inst.nullID(),
+		ClaimSeconds: aggClaimTTL.Seconds(),
+		ID:           feedID,
+	})
+}
+
+func (inst *aggInstance) releaseFeed(feed database.Feed) {
+	if err := inst.db.ReleaseFeedClaim(context.Background(), database.ReleaseFeedClaimParams{
+		ID:        feed.ID,
+		ClaimedBy: inst.nullID(),
+	}); err != nil {
+		feedLogger(feed).Error("couldn't release feed claim", "err", err)
+	}
+}
+
+// stop deregisters the instance, which releases its claims, and gives up
+// the leader lease so another instance can take over straight away. The
+// heartbeat loop is stopped first so it can't register the instance again.
+func (inst *aggInstance) stop() {
+	inst.cancel()
+	<-inst.done
+	ctx := context.Background()
+	if err := inst.db.ReleaseLease(ctx, database.ReleaseLeaseParams{
+		Name:   leaderLease,
+		Holder: inst.id,
+	}); err != nil {
+		slog.Error("couldn't release leader lease", "err", err)
+	}
+	if err := inst.db.DeleteInstance(ctx, inst.id); err != nil {
+		slog.Error("couldn't deregister agg instance", "instance_id", inst.id, "err", err)
+	}
+	slog.Info("stopped agg instance", "instance_id", inst.id)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// postSummary counts what happened to the items of a fetched feed.
+type postSummary struct {
+	New     int
+	Updated int
+	Skipped int
+}
+
+func (p *postSummary) add(other postSummary) {
+	p.New += other.New
+	p.Updated += other.Updated
+	p.Skipped += other.Skipped
+}
+
+func (p postSummary) String() string {
+	return fmt.Sprintf("%d new, %d updated, %d skipped", p.New, p.Updated, p.Skipped)
+}
+
+func aggHandler(state *state, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	once := fs.Bool("once", false, "fetch every due feed once and exit")
+	feedURL := fs.String("feed", "", "fetch a single feed and exit")
+	pidfile := fs.String("pidfile", "", "write the process ID to this file while running")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	usage := fmt.Errorf("usage: %v [--once [min_age] | --feed <url> | [--pidfile <path>] <time_between_reqs>]", cmd.Name)
+
+	if *feedURL != "" {
+		if fs.NArg() != 0 || *once {
+			return usage
+		}
+		return fetchOne(state, *feedURL)
+	}
+	if *once {
+		if fs.NArg() > 1 {
+			return usage
+		}
+		minAge := time.Duration(0)
+		if fs.NArg() == 1 {
+			d, err := time.ParseDuration(fs.Arg(0))
+			if err != nil {
+				return fmt.Errorf("invalid duration: %w", err)
+			}
+			minAge = d
+		}
+		return scrapeDueFeeds(state, minAge)
+	}
+
+	if fs.NArg() != 1 {
+		return usage
+	}
+	timeBetweenRequests, err := time.ParseDuration(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	if *pidfile != "" {
+		if err := writePidfile(*pidfile); err != nil {
+			return err
+		}
+		defer os.Remove(*pidfile)
+	}
+	return runAgg(state, timeBetweenRequests)
+}
+
+// runAgg runs the aggregator until it receives SIGINT or SIGTERM.
+func runAgg(state *state, timeBetweenRequests time.Duration) error {
+	jobs, err := loadSchedule(state.cfg.Jobs)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	inst, err := startAggInstance(ctx, state.db, true)
+	if err != nil {
+		return fmt.Errorf("couldn't register agg instance: %w", err)
+	}
+	defer inst.stop()
+	state.instance = inst
+	state.status = newAggStatus()
+
+	if state.cfg.WebSubListenAddr != "" && state.cfg.WebSubCallbackURL != "" {
+		state.webSub = newWebSubscriber(state.db, state.cfg.WebSubCallbackURL)
+		go state.webSub.serve(state.cfg.WebSubListenAddr)
+		go state.webSub.renewLoop(ctx, inst.isLeader)
+	}
+	if state.cfg.MetricsAddr != "" {
+		go serveMetrics(state.cfg.MetricsAddr)
+	}
+	if state.cfg.StatusAddr != "" {
+		go serveStatus(state, state.cfg.StatusAddr)
+	}
+	if len(jobs) > 0 {
+		go runScheduler(ctx, state, jobs, state.cfg.Jobs)
+	}
+	slog.Info("collecting feeds", "interval", timeBetweenRequests)
+	ticker := time.NewTicker(timeBetweenRequests)
+	defer ticker.Stop()
+	for {
+		if state.cfg.MetricsAddr != "" {
+			updateFeedsDue(state.db)
+		}
+		scrapeFeeds(ctx, state)
+		select {
+		case <-ctx.Done():
+			slog.Info("shutting down")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func fetchHandler(state *state, cmd command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage %s <feed_URL>", cmd.Name)
+	}
+	return fetchOne(state, cmd.Args[0])
+}
+
+// fetchOne refreshes a single feed on demand, whatever its status, unless
+// a running agg is fetching it already.
+func fetchOne(s *state, feedURL string) error {
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, feedURL)
+	if err != nil {
+		return fmt.Errorf("couldn't find feed: %w", err)
+	}
+	inst, err := startAggInstance(ctx, s.db, false)
+	if err != nil {
+		return fmt.Errorf("couldn't register agg instance: %w", err)
+	}
+	defer inst.stop()
+	feed, err = inst.claimFeed(ctx, feed.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("feed %s is being fetched by a running agg right now", feedURL)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't claim feed: %w", err)
+	}
+	defer inst.releaseFeed(feed)
+
+	summary, err := scrapeFeed(s, feed)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch feed %s: %w", feed.Name, err)
+	}
+	fmt.Printf("Feed %s: %s\n", feed.Name, summary)
+	if feed.Status != feedStatusActive {
+		fmt.Printf("Note: this feed is %s, use resumefeed to put it back in the rotation\n", feed.Status)
+	}
+	return nil
+}
+
+// scrapeDueFeeds fetches every active feed not fetched within minAge once,
+// skipping the ones a running agg is fetching already.
+func scrapeDueFeeds(s *state, minAge time.Duration) error {
+	ctx := context.Background()
+	feeds, err := s.db.GetFeedsDueForFetch(ctx, minAge.Seconds())
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds to fetch: %w", err)
+	}
+	inst, err := startAggInstance(ctx, s.db, false)
+	if err != nil {
+		return fmt.Errorf("couldn't register agg instance: %w", err)
+	}
+	defer inst.stop()
+
+	var total postSummary
+	fetched, failed, claimed := 0, 0, 0
+	for _, due := range feeds {
+		feed, err := inst.claimFeed(ctx, due.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			claimed++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't claim feed %s: %w", due.Url, err)
+		}
+		summary, err := scrapeFeed(s, feed)
+		inst.releaseFeed(feed)
+		fetched++
+		if err != nil {
+			failed++
+			continue
+		}
+		total.add(summary)
+	}
+	fmt.Printf("Fetched %d feeds (%d failed, %d skipped as already being fetched): %s\n", fetched, failed, claimed, total)
+	return nil
+}
+
+func scrapeFeeds(ctx context.Context, s *state) {
+	feed, err := s.instance.claimNextFeed(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.Debug("no feed to fetch, all active feeds are claimed")
+		return
+	}
+	if err != nil {
+		slog.Error("couldn't get next feed to fetch", "err", err)
+		return
+	}
+	defer s.instance.releaseFeed(feed)
+	feedLogger(feed).Debug("found a feed to fetch")
+	scrapeFeed(s, feed)
+}
+
+func scrapeFeed(s *state, feed database.Feed) (summary postSummary, err error) {
+	s.status.begin(feed)
+	defer func() { s.status.end(feed, err) }()
+
+	db := s.db
+	logger := feedLogger(feed)
+	_, err = db.MarkFeedFetched(context.Background(), feed.ID)
+	if err != nil {
+		logger.Error("couldn't mark feed fetched", "err", err)
+		return postSummary{}, err
+	}
+
+	start := time.Now()
+	feedData, attempts, err := fetchFeedWithRetry(context.Background(), feed.Url)
+	duration := time.Since(start)
+	feedFetchDuration.observe(duration.Seconds())
+	if err != nil {
+		logger.Warn("couldn't collect feed", "attempts", attempts, "duration", duration, "err", err)
+		feedFetches.inc("failure")
+		feedFetchFailures.inc(fetchFailureReason(err))
+		recordFetchFailure(db, feed, err)
+		return postSummary{}, err
+	}
+	feedFetches.inc("success")
+	lastSuccessfulFetch.set(float64(time.Now().Unix()))
+	recordFetchSuccess(db, feed, feedData.Channel.Link)
+	summary = savePosts(db, feed, feedData.Channel.Item)
+	logger.Info("feed collected",
+		"items", len(feedData.Channel.Item),
+		"new", summary.New,
+		"updated", summary.Updated,
+		"skipped", summary.Skipped,
+		"retries", attempts-1,
+		"duration", duration,
+	)
+	if s.webSub != nil {
+		s.webSub.subscribeIfAdvertised(feed, feedData)
+	}
+	return summary, nil
+}
+
+// savePosts stores feed items as posts, updating ones whose title,
+// description, content or publish date changed, and runs users' rules on the
+// new ones. It is shared by polling and WebSub pushes.
+func savePosts(db *database.Queries, feed database.Feed, items []RSSItem) postSummary {
+	var summary postSummary
+	for _, item := range items {
+		publishedAt := sql.NullTime{}
+		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
+			publishedAt = sql.NullTime{
+				Time:  t,
+				Valid: true,
+			}
+		}
+
+		postID := uuid.New()
+		inserted, err := db.UpsertPost(context.Background(), database.UpsertPostParams{
+			ID:        postID,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			FeedID:    feed.ID,
+			Title:     item.Title,
+			Description: sql.NullString{
+				String: item.Description,
+				Valid:  true,
+			},
+			Url:         item.Link,
+			PublishedAt: publishedAt,
+			Content: sql.NullString{
+				String: item.Content,
+				Valid:  item.Content != "",
+			},
+			Author: sql.NullString{
+				String: item.author(),
+				Valid:  item.author() != "",
+			},
+			Categories: item.categories(),
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// The post exists and nothing about it changed.
+			summary.Skipped++
+			postsStored.inc("duplicate")
+		case err != nil:
+			feedLogger(feed).Error("couldn't save post", "post_url", item.Link, "err", err)
+			summary.Skipped++
+			postsStored.inc("error")
+		case inserted:
+			summary.New++
+			postsStored.inc("inserted")
+			applyRules(db, feed, postID)
+		default:
+			summary.Updated++
+			postsStored.inc("updated")
+		}
+	}
+	return summary
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	sortPublished = "published"
+	sortFetched   = "fetched"
+	sortFeed      = "feed"
+)
+
+// browseCursor marks the last post of a page so the next page can continue
+// right after it, independent of posts added in the meantime.
+type browseCursor struct {
+	SortTime time.Time `json:"t"`
+	FeedName string    `json:"f,omitempty"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func (c browseCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBrowseCursor(s string) (browseCursor, error) {
+	var c browseCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor %q", s)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid cursor %q", s)
+	}
+	return c, nil
+}
+
+// parseBrowseTime accepts a date (2006-01-02), an RFC 3339 timestamp or a
+// duration relative to now such as 36h or 7d.
+func parseBrowseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use YYYY-MM-DD, RFC 3339 or a duration like 7d", value)
+}
+
+// followedFeedIDs returns the IDs of the followed feeds whose name, URL or
+// display name is name.
+func followedFeedIDs(s *state, user database.User, name string) ([]uuid.UUID, error) {
+	ids, err := s.db.GetFollowedFeedIDsByName(context.Background(), database.GetFollowedFeedIDsByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get feed %q: %w", name, err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("you don't follow a feed named %q", name)
+	}
+	return ids, nil
+}
+
+func browseHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	all := fs.Bool("all", false, "include posts that have already been read")
+	keepUnread := fs.Bool("keep-unread", false, "don't mark the displayed posts as read")
+	feed := fs.String("feed", "", "only show posts from the feed with this name or URL")
+	folder := fs.String("folder", "", "only show posts from feeds in this folder")
+	since := fs.String("since", "", "only show posts published at or after this time")
+	until := fs.String("until", "", "only show posts published before this time")
+	savedOnly := fs.Bool("saved", false, "only show saved posts")
+	author := fs.String("author", "", "only show posts whose author contains this text")
+	keyword := fs.String("keyword", "", "only show posts whose title or description contains this text")
+	tag := fs.String("tag", "", "only show posts with this tag")
+	search := fs.String("search", "", "only show posts matching this saved search")
+	after := fs.String("after", "", "cursor printed by a previous browse to show the next page")
+	sortBy := fs.String("sort", sortPublished, "sort order: published, fetched or feed")
+	limit := fs.Int("limit", 2, "maximum number of posts to show")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() == 1 {
+		if specifiedLimit, err := strconv.Atoi(fs.Arg(0)); err == nil {
+			*limit = specifiedLimit
+		} else {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+	} else if fs.NArg() > 1 {
+		return fmt.Errorf("usage %s [flags] [limit]", cmd.Name)
+	}
+	if *limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	switch *sortBy {
+	case sortPublished, sortFetched, sortFeed:
+	default:
+		return fmt.Errorf("invalid sort %q: use published, fetched or feed", *sortBy)
+	}
+
+	params := database.BrowsePostsParams{
+		UserID:    user.ID,
+		Sort:      *sortBy,
+		Folder:    sql.NullString{String: *folder, Valid: *folder != ""},
+		SavedOnly: *savedOnly,
+		Author:    sql.NullString{String: *author, Valid: *author != ""},
+		Keyword:   sql.NullString{String: *keyword, Valid: *keyword != ""},
+		Tag:       sql.NullString{String: *tag, Valid: *tag != ""},
+		Limit:     int32(*limit),
+	}
+	if *feed != "" {
+		feedIDs, err := followedFeedIDs(s, user, *feed)
+		if err != nil {
+			return err
+		}
+		params.FeedIds = feedIDs
+	}
+	now := time.Now().UTC()
+	if *since != "" {
+		t, err := parseBrowseTime(*since, now)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: t, Valid: true}
+	}
+	if *until != "" {
+		t, err := parseBrowseTime(*until, now)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: t, Valid: true}
+	}
+	if *search != "" {
+		savedSearch, err := lookupSavedSearch(s, user, *search)
+		if err != nil {
+			return err
+		}
+		if err := applySavedSearch(savedSearch, &params, now); err != nil {
+			return err
+		}
+	}
+	// A tag, from the flag or the saved search, shows read posts as well.
+	params.UnreadOnly = !*all && !*savedOnly && !params.Tag.Valid
+	if *after != "" {
+		cursor, err := decodeBrowseCursor(*after)
+		if err != nil {
+			return err
+		}
+		params.AfterTime = sql.NullTime{Time: cursor.SortTime, Valid: true}
+		params.AfterFeed = sql.NullString{String: cursor.FeedName, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	posts, err := s.db.BrowsePosts(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't get posts for user: %w", err)
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	shortIDs, err := postShortIDs(s, user, ids)
+	if err != nil {
+		return err
+	}
+
+	if params.UnreadOnly {
+		fmt.Printf("Found %d unread posts for user %s:\n", len(posts), user.Name)
+	} else {
+		fmt.Printf("Found %d posts for user %s:\n", len(posts), user.Name)
+	}
+	for _, post := range posts {
+		var markers []string
+		if post.Read {
+			markers = append(markers, "read")
+		}
+		if post.Saved {
+			markers = append(markers, "saved")
+		}
+		marker := ""
+		if len(markers) > 0 {
+			marker = " (" + strings.Join(markers, ", ") + ")"
+		}
+		fmt.Printf("%s from %s%s\n", post.SortTime.Format("Mon Jan 2"), feedLabel(post.FeedName, post.FeedColor, post.FeedEmoji), marker)
+		fmt.Printf("--- %s ---\n", post.Title)
+		if post.Author.Valid {
+			fmt.Printf("    by %s\n", post.Author.String)
+		}
+		if len(post.Tags) > 0 {
+			fmt.Printf("    tagged %s\n", strings.Join(post.Tags, ", "))
+		}
+		fmt.Printf("    %v\n", post.Description.String)
+		fmt.Printf("Link: %s\n", post.Url)
+		fmt.Printf("ID:   %s\n", shortIDs[post.ID])
+		fmt.Println("=====================================")
+
+		if !*keepUnread && !post.Read {
+			if err := markPostRead(s, user, post.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(posts) == *limit {
+		last := posts[len(posts)-1]
+		cursor := browseCursor{SortTime: last.SortTime, ID: last.ID}
+		if *sortBy == sortFeed {
+			cursor.FeedName = last.FeedName
+		}
+		fmt.Printf("More posts may be available with --after %s\n", cursor.encode())
+	}
+	return nil
+}
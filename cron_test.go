@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-3 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"@often",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // empty when the schedule never matches
+	}{
+		{"every 15 minutes", "*/15 * * * *", "2025-01-15 10:30", "2025-01-15 10:45"},
+		{"strictly after", "30 10 * * *", "2025-01-15 10:30", "2025-01-16 10:30"},
+		{"next minute", "31 10 * * *", "2025-01-15 10:30", "2025-01-15 10:31"},
+		{"daily at 3", "0 3 * * *", "2025-01-15 10:30", "2025-01-16 03:00"},
+		{"hourly macro", "@hourly", "2025-01-15 10:30", "2025-01-15 11:00"},
+		{"stepped range", "*/20 9-17/4 * * *", "2025-01-15 17:50", "2025-01-16 09:00"},
+		{"list", "0 8,20 * * *", "2025-01-15 10:30", "2025-01-15 20:00"},
+		{"day rollover", "0 0 * * *", "2025-01-31 23:59", "2025-02-01 00:00"},
+		{"year rollover", "@yearly", "2025-12-31 23:59", "2026-01-01 00:00"},
+		{"month without the day", "0 0 31 * *", "2025-01-31 00:00", "2025-03-31 00:00"},
+		{"month restricted", "0 0 1 6 *", "2025-07-01 00:00", "2026-06-01 00:00"},
+		{"leap day", "0 0 29 2 *", "2025-01-01 00:00", "2028-02-29 00:00"},
+		{"weekdays", "0 0 * * 1-5", "2025-01-17 10:30", "2025-01-20 00:00"},
+		{"0 is Sunday", "0 0 * * 0", "2025-01-15 10:30", "2025-01-19 00:00"},
+		{"7 is Sunday", "0 0 * * 7", "2025-01-15 10:30", "2025-01-19 00:00"},
+		{"range ending in 7", "0 0 * * 6-7", "2025-01-18 00:00", "2025-01-19 00:00"},
+		{"day of month or week, week first", "0 0 13 * 5", "2025-01-15 10:30", "2025-01-17 00:00"},
+		{"day of month or week, month first", "0 0 13 * 5", "2025-05-10 00:00", "2025-05-13 00:00"},
+		{"day of week with any day of month", "0 0 * * 5", "2025-05-10 00:00", "2025-05-16 00:00"},
+		{"stepped day of month is unrestricted", "0 0 */2 * 5", "2025-05-14 00:00", "2025-05-23 00:00"},
+		{"never: February 31", "0 0 31 2 *", "2025-01-01 00:00", ""},
+		{"never: February 30", "0 0 30 2 *", "2025-01-01 00:00", ""},
+		{"never: April 31", "0 0 31 4 *", "2025-01-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.expr, err)
+			}
+			got := s.next(at(tt.from))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("next(%s) = %s, want no match", tt.from, got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("next(%s) = %s, want %s", tt.from, got, want)
+			}
+		})
+	}
+}
+
+func TestCronNextKeepsLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, err := parseCron("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.next(time.Date(2025, 1, 15, 10, 30, 0, 0, loc))
+	if want := time.Date(2025, 1, 16, 3, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("next = %s, want %s", got, want)
+	}
+}
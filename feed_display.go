@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// feedColors maps the colors a feed can be given to their ANSI codes.
+var feedColors = map[string]string{
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+}
+
+// feedLabel renders a feed name with the user's emoji in front of it and in
+// the user's color when printing to a terminal.
+func feedLabel(name string, color, emoji sql.NullString) string {
+	if code, ok := feedColors[color.String]; ok && isTerminal(os.Stdout) {
+		name = "\x1b[" + code + "m" + name + "\x1b[0m"
+	}
+	if emoji.Valid {
+		name = emoji.String + " " + name
+	}
+	return name
+}
+
+func aliasHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.String("name", "", "name to show for the feed instead of its own")
+	color := fs.String("color", "", "color for the feed name: red, green, yellow, blue, magenta or cyan")
+	fs.String("emoji", "", "emoji to show before the feed name")
+	reset := fs.Bool("clear", false, "go back to the feed's own name, without color or emoji")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage %s [--name name] [--color color] [--emoji emoji] [--clear] <feed_URL>", cmd.Name)
+	}
+	if *color != "" {
+		if _, ok := feedColors[*color]; !ok {
+			colors := make([]string, 0, len(feedColors))
+			for c := range feedColors {
+				colors = append(colors, c)
+			}
+			slices.Sort(colors)
+			return fmt.Errorf("invalid color %q: use one of %s", *color, strings.Join(colors, ", "))
+		}
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+	i := slices.IndexFunc(follows, func(ff database.GetFeedFollowsForUserRow) bool {
+		return ff.FeedUrl == fs.Arg(0)
+	})
+	if i < 0 {
+		return fmt.Errorf("you don't follow %s", fs.Arg(0))
+	}
+	ff := follows[i]
+
+	params := database.SetFeedFollowDisplayParams{
+		UserID:      user.ID,
+		FeedID:      ff.FeedID,
+		DisplayName: ff.DisplayName,
+		Color:       ff.Color,
+		Emoji:       ff.Emoji,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if *reset {
+		params.DisplayName = sql.NullString{}
+		params.Color = sql.NullString{}
+		params.Emoji = sql.NullString{}
+	}
+	// Only the flags that were given change; the rest keep their values.
+	fs.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch f.Name {
+		case "name":
+			params.DisplayName = sql.NullString{String: value, Valid: value != ""}
+		case "color":
+			params.Color = sql.NullString{String: value, Valid: value != ""}
+		case "emoji":
+			params.Emoji = sql.NullString{String: value, Valid: value != ""}
+		}
+	})
+	if _, err := s.db.SetFeedFollowDisplay(context.Background(), params); err != nil {
+		return fmt.Errorf("couldn't update feed display: %w", err)
+	}
+
+	displayName := ff.FeedName
+	if params.DisplayName.Valid {
+		displayName = params.DisplayName.String
+	}
+	fmt.Printf("%s is now shown as %s\n", ff.FeedUrl, feedLabel(displayName, params.Color, params.Emoji))
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const feedUsage = "usage %s show <feed_URL> | rename <feed_URL> <new_name> | seturl <feed_URL> <new_URL> | retain [--days n] [--posts n] [--clear] <feed_URL> | delete [--force] <feed_URL>"
+
+func feedHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf(feedUsage, cmd.Name)
+	}
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "show":
+		if len(args) != 1 {
+			return fmt.Errorf(feedUsage, cmd.Name)
+		}
+		return showFeed(s, args[0])
+	case "rename":
+		if len(args) < 2 {
+			return fmt.Errorf(feedUsage, cmd.Name)
+		}
+		return renameFeed(s, user, args[0], strings.Join(args[1:], " "))
+	case "seturl":
+		if len(args) != 2 {
+			return fmt.Errorf(feedUsage, cmd.Name)
+		}
+		return setFeedURL(s, user, args[0], args[1])
+	case "retain":
+		return setFeedRetention(s, user, command{Name: cmd.Name + " retain", Args: args})
+	case "delete":
+		return deleteFeed(s, user, command{Name: cmd.Name + " delete", Args: args})
+	default:
+		return fmt.Errorf(feedUsage, cmd.Name)
+	}
+}
+
+func lookupFeed(s *state, url string) (database.Feed, error) {
+	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return feed, fmt.Errorf("no feed with URL %s", url)
+		}
+		return feed, fmt.Errorf("couldn't find feed: %w", err)
+	}
+	return feed, nil
+}
+
+// lookupOwnedFeed finds a feed the user may change: one they added, or any
+// feed when they're listed in admin_users.
+func lookupOwnedFeed(s *state, user database.User, url string) (database.Feed, error) {
+	feed, err := lookupFeed(s, url)
+	if err != nil {
+		return feed, err
+	}
+	if feed.UserID != user.ID && !s.cfg.IsAdmin(user.Name) {
+		return feed, fmt.Errorf("feed %s was added by another user; only they or an admin can change it", url)
+	}
+	return feed, nil
+}
+
+func showFeed(s *state, url string) error {
+	feed, err := lookupFeed(s, url)
+	if err != nil {
+		return err
+	}
+	stats, err := s.db.GetFeedStats(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed stats: %w", err)
+	}
+	owner, err := s.db.GetUserByID(context.Background(), feed.UserID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed owner: %w", err)
+	}
+
+	fmt.Printf("* ID:            %s\n", feed.ID)
+	fmt.Printf("* Name:          %s\n", feed.Name)
+	fmt.Printf("* URL:           %s\n", feed.Url)
+	if feed.SiteUrl.Valid {
+		fmt.Printf("* Site:          %s\n", feed.SiteUrl.String)
+	}
+	fmt.Printf("* Added by:      %s on %s\n", owner.Name, feed.CreatedAt.Format(time.DateTime))
+	fmt.Printf("* Status:        %s\n", feed.Status)
+	fmt.Printf("* Followers:     %d\n", stats.Followers)
+	fmt.Printf("* Posts:         %d\n", stats.Posts)
+	if stats.LatestPostAt.Valid {
+		fmt.Printf("* Latest post:   %s\n", stats.LatestPostAt.Time.Format(time.DateTime))
+	}
+	fmt.Printf("* Retention:     %s\n", describeRetention(feed))
+	if feed.LastFetchedAt.Valid {
+		fmt.Printf("* Last fetched:  %s\n", feed.LastFetchedAt.Time.Format(time.DateTime))
+	} else {
+		fmt.Println("* Last fetched:  never")
+	}
+	if feed.LastError.Valid {
+		fmt.Printf("* Last error:    %s\n", feed.LastError.String)
+	}
+	if feed.LastStatusCode.Valid {
+		fmt.Printf("* Last status:   %d\n", feed.LastStatusCode.Int32)
+	}
+	if feed.FailingSince.Valid {
+		fmt.Printf("* Failing since: %s\n", feed.FailingSince.Time.Format(time.DateTime))
+	}
+	sub, err := s.db.GetWebSubSubscriptionByFeed(context.Background(), feed.ID)
+	switch {
+	case err == nil:
+		fmt.Printf("* WebSub:        %s via %s\n", sub.Status, sub.HubUrl)
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("couldn't get WebSub subscription: %w", err)
+	}
+	return nil
+}
+
+func renameFeed(s *state, user database.User, url, name string) error {
+	feed, err := lookupOwnedFeed(s, user, url)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.RenameFeed(context.Background(), database.RenameFeedParams{
+		ID:        feed.ID,
+		Name:      name,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't rename feed: %w", err)
+	}
+	fmt.Printf("Feed %s has been renamed to %s\n", feed.Name, name)
+	return nil
+}
+
+func setFeedURL(s *state, user database.User, url, newURL string) error {
+	feed, err := lookupOwnedFeed(s, user, url)
+	if err != nil {
+		return err
+	}
+	if !validFeedURL(newURL) {
+		return fmt.Errorf("%q is not an http(s) URL", newURL)
+	}
+	if _, err := s.db.GetFeedByURL(context.Background(), newURL); err == nil {
+		return fmt.Errorf("there is already a feed with URL %s", newURL)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("couldn't check for an existing feed: %w", err)
+	}
+
+	_, err = s.db.SetFeedURL(context.Background(), database.SetFeedURLParams{
+		ID:        feed.ID,
+		Url:       newURL,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't update feed URL: %w", err)
+	}
+	// The WebSub subscription was for the old topic; agg subscribes again on
+	// the next fetch if the new URL advertises a hub.
+	if err := s.db.DeleteWebSubSubscription(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("couldn't remove WebSub subscription: %w", err)
+	}
+	fmt.Printf("Feed %s now points to %s\n", feed.Name, newURL)
+	return nil
+}
+
+// setFeedRetention overrides the global retention settings for one feed.
+// Only the limits given change, and 0 turns that limit off for the feed.
+// --clear goes back to the global settings for the limits not given.
+func setFeedRetention(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.Int("days", 0, "keep posts for this many days")
+	fs.Int("posts", 0, "keep this many of the newest posts")
+	reset := fs.Bool("clear", false, "go back to the global retention settings")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || fs.NFlag() == 0 {
+		return fmt.Errorf("usage %s [--days n] [--posts n] [--clear] <feed_URL>", cmd.Name)
+	}
+	feed, err := lookupOwnedFeed(s, user, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	params := database.SetFeedRetentionParams{
+		ID:          feed.ID,
+		RetainDays:  feed.RetainDays,
+		RetainPosts: feed.RetainPosts,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if *reset {
+		params.RetainDays = sql.NullInt32{}
+		params.RetainPosts = sql.NullInt32{}
+	}
+	// Only the flags that were given change; the rest keep their values.
+	var invalid error
+	fs.Visit(func(f *flag.Flag) {
+		var limit *sql.NullInt32
+		switch f.Name {
+		case "days":
+			limit = &params.RetainDays
+		case "posts":
+			limit = &params.RetainPosts
+		default:
+			return
+		}
+		n := f.Value.(flag.Getter).Get().(int)
+		if n < 0 {
+			invalid = fmt.Errorf("--%s can't be negative", f.Name)
+			return
+		}
+		*limit = sql.NullInt32{Int32: int32(n), Valid: true}
+	})
+	if invalid != nil {
+		return invalid
+	}
+
+	feed, err = s.db.SetFeedRetention(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't set feed retention: %w", err)
+	}
+	fmt.Printf("Feed %s now keeps %s\n", feed.Name, describeRetention(feed))
+	return nil
+}
+
+func describeRetention(feed database.Feed) string {
+	days := "posts for the global number of days"
+	if feed.RetainDays.Valid {
+		days = fmt.Sprintf("posts for %d days", feed.RetainDays.Int32)
+		if feed.RetainDays.Int32 == 0 {
+			days = "posts of any age"
+		}
+	}
+	posts := "up to the global number of posts"
+	if feed.RetainPosts.Valid {
+		posts = fmt.Sprintf("up to %d posts", feed.RetainPosts.Int32)
+		if feed.RetainPosts.Int32 == 0 {
+			posts = "any number of posts"
+		}
+	}
+	return days + ", " + posts
+}
+
+func deleteFeed(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	force := fs.Bool("force", false, "delete the feed even though other users follow it")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage %s [--force] <feed_URL>", cmd.Name)
+	}
+	feed, err := lookupOwnedFeed(s, user, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	stats, err := s.db.GetFeedStats(context.Background(), feed.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed stats: %w", err)
+	}
+	others, err := s.db.CountOtherFeedFollowers(context.Background(), database.CountOtherFeedFollowersParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't count feed followers: %w", err)
+	}
+	if others > 0 && !*force {
+		return fmt.Errorf("%d other users follow %s; pass --force to delete it and its %d posts for all of them", others, feed.Url, stats.Posts)
+	}
+
+	if _, err := s.db.DeleteFeed(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("couldn't delete feed: %w", err)
+	}
+	fmt.Printf("Feed %s has been deleted along with %d posts\n", feed.Name, stats.Posts)
+	return nil
+}
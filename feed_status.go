@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	feedStatusActive = "active"
+	feedStatusPaused = "paused"
+	feedStatusDead   = "dead"
+
+	// A feed that keeps answering 404 for this long is treated as gone.
+	deadFeedAfter = 21 * 24 * time.Hour
+	// Any other unbroken failure streak this long takes the feed out of the
+	// rotation until someone resumes it.
+	pauseFeedAfter = 7 * 24 * time.Hour
+)
+
+// recordFetchFailure stores the error on the feed and moves it to paused or
+// dead when the failure history says it should no longer be polled.
+func recordFetchFailure(db *database.Queries, feed database.Feed, fetchErr error) {
+	var statusCode sql.NullInt32
+	var statusErr *httpStatusError
+	if errors.As(fetchErr, &statusErr) {
+		statusCode = sql.NullInt32{Int32: int32(statusErr.StatusCode), Valid: true}
+	}
+	now := time.Now().UTC()
+	updated, err := db.RecordFeedFailure(context.Background(), database.RecordFeedFailureParams{
+		ID:             feed.ID,
+		LastError:      sql.NullString{String: fetchErr.Error(), Valid: true},
+		LastStatusCode: statusCode,
+		FailingSince:   sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		feedLogger(feed).Error("couldn't record feed failure", "err", err)
+		return
+	}
+	next := nextFeedStatus(updated, now)
+	if next == updated.Status {
+		return
+	}
+	if _, err := db.SetFeedStatus(context.Background(), database.SetFeedStatusParams{
+		ID:     feed.ID,
+		Status: next,
+	}); err != nil {
+		feedLogger(feed).Error("couldn't update feed status", "status", next, "err", err)
+		return
+	}
+	feedLogger(feed).Warn("feed status changed", "status", next, "err", fetchErr)
+}
+
+// recordFetchSuccess clears any failure streak left on the feed and keeps
+// the link to the feed's website up to date.
+func recordFetchSuccess(db *database.Queries, feed database.Feed, siteURL string) {
+	if !feed.FailingSince.Valid && !feed.LastError.Valid && feed.SiteUrl.String == siteURL {
+		return
+	}
+	err := db.RecordFeedSuccess(context.Background(), database.RecordFeedSuccessParams{
+		ID:      feed.ID,
+		SiteUrl: sql.NullString{String: siteURL, Valid: siteURL != ""},
+	})
+	if err != nil {
+		feedLogger(feed).Error("couldn't clear feed failure state", "err", err)
+	}
+}
+
+func nextFeedStatus(feed database.Feed, now time.Time) string {
+	if feed.Status != feedStatusActive || !feed.FailingSince.Valid {
+		return feed.Status
+	}
+	switch {
+	case feed.LastStatusCode.Int32 == http.StatusGone:
+		return feedStatusDead
+	case feed.NotFoundSince.Valid:
+		// A feed answering 404 stays in the rotation rather than being
+		// paused, so it's still polled when it has been gone long enough
+		// to be marked dead.
+		if now.Sub(feed.NotFoundSince.Time) >= deadFeedAfter {
+			return feedStatusDead
+		}
+	case now.Sub(feed.FailingSince.Time) >= pauseFeedAfter:
+		return feedStatusPaused
+	}
+	return feed.Status
+}
+
+func attentionHandler(s *state, cmd command, user database.User) error {
+	feeds, err := s.db.GetFeedsNeedingAttention(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feeds needing attention: %w", err)
+	}
+	if len(feeds) == 0 {
+		fmt.Println("All followed feeds are healthy.")
+		return nil
+	}
+
+	fmt.Printf("Feeds needing attention for user %s:\n", user.Name)
+	for _, feed := range feeds {
+		fmt.Printf("* %s (%s) - %s\n", feed.Name, feed.Url, feed.Status)
+		if feed.FailingSince.Valid {
+			fmt.Printf("    Failing since: %s\n", feed.FailingSince.Time.Format(time.DateTime))
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("    Last error:    %s\n", feed.LastError.String)
+		}
+	}
+	return nil
+}
+
+func pauseFeedHandler(s *state, cmd command, user database.User) error {
+	return setFeedStatusHandler(s, cmd, user, feedStatusPaused)
+}
+
+func resumeFeedHandler(s *state, cmd command, user database.User) error {
+	return setFeedStatusHandler(s, cmd, user, feedStatusActive)
+}
+
+// setFeedStatusHandler changes the status of a feed the user owns. Pausing
+// or resuming a feed changes it for every follower, so it's limited to the
+// feed's owner and admins.
+func setFeedStatusHandler(s *state, cmd command, user database.User, status string) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage %s <feed_URL>", cmd.Name)
+	}
+	feed, err := lookupOwnedFeed(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	if status == feedStatusActive {
+		err := s.db.RecordFeedSuccess(context.Background(), database.RecordFeedSuccessParams{
+			ID:      feed.ID,
+			SiteUrl: feed.SiteUrl,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't reset feed errors: %w", err)
+		}
+	}
+	feed, err = s.db.SetFeedStatus(context.Background(), database.SetFeedStatusParams{
+		ID:     feed.ID,
+		Status: status,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't update feed status: %w", err)
+	}
+	fmt.Printf("Feed %s is now %s\n", feed.Name, feed.Status)
+	return nil
+}
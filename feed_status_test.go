@@ -0,0 +1,84 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+func TestNextFeedStatus(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	since := func(d time.Duration) sql.NullTime {
+		return sql.NullTime{Time: now.Add(-d), Valid: true}
+	}
+	status := func(code int32) sql.NullInt32 {
+		return sql.NullInt32{Int32: code, Valid: true}
+	}
+
+	tests := []struct {
+		name string
+		feed database.Feed
+		want string
+	}{
+		{
+			name: "healthy",
+			feed: database.Feed{Status: feedStatusActive},
+			want: feedStatusActive,
+		},
+		{
+			name: "gone on first failure",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(0), LastStatusCode: status(410)},
+			want: feedStatusDead,
+		},
+		{
+			name: "not found for a day",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(day), LastStatusCode: status(404), NotFoundSince: since(day)},
+			want: feedStatusActive,
+		},
+		{
+			name: "not found for longer than a pause",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(10 * day), LastStatusCode: status(404), NotFoundSince: since(10 * day)},
+			want: feedStatusActive,
+		},
+		{
+			name: "not found for 21 days",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(21 * day), LastStatusCode: status(404), NotFoundSince: since(21 * day)},
+			want: feedStatusDead,
+		},
+		{
+			name: "timeout during a run of 404s",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(30 * day), NotFoundSince: since(22 * day)},
+			want: feedStatusDead,
+		},
+		{
+			name: "server errors for six days",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(6 * day), LastStatusCode: status(503)},
+			want: feedStatusActive,
+		},
+		{
+			name: "server errors for seven days",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(7 * day), LastStatusCode: status(503)},
+			want: feedStatusPaused,
+		},
+		{
+			name: "404s ended by a server error",
+			feed: database.Feed{Status: feedStatusActive, FailingSince: since(25 * day), LastStatusCode: status(500)},
+			want: feedStatusPaused,
+		},
+		{
+			name: "paused stays paused",
+			feed: database.Feed{Status: feedStatusPaused, FailingSince: since(30 * day), LastStatusCode: status(410)},
+			want: feedStatusPaused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextFeedStatus(tt.feed, now); got != tt.want {
+				t.Errorf("nextFeedStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
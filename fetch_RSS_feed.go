@@ -3,26 +3,165 @@ package main
 import (
 	"context"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"html"
 	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
+const (
+	fetchMaxAttempts = 4
+	fetchBaseBackoff = 500 * time.Millisecond
+	fetchMaxBackoff  = 8 * time.Second
+)
+
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// AtomLinks must come before Link so that atom:link elements
+		// (rel="self", rel="hub") don't overwrite the channel's own link.
+		AtomLinks   []RSSAtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string        `xml:"link"`
+		Description string        `xml:"description"`
+		Item        []RSSItem     `xml:"item"`
 	} `xml:"channel"`
 }
 
+type RSSAtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+// atomLink returns the href of the first atom:link with the given rel.
+func (f *RSSFeed) atomLink(rel string) string {
+	for _, link := range f.Channel.AtomLinks {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
+
 type RSSItem struct {
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	// Content is the full article body from the RSS content module, when
+	// the feed provides one.
+	Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	// Creator is the Dublin Core author most feeds use; Author is the RSS
+	// element, which is usually an email address.
+	Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Author  string `xml:"author"`
+	// Categories are the item's category elements, used by mute filters.
+	Categories []string `xml:"category"`
+}
+
+// categories returns the item's non-empty categories, never nil since the
+// posts column doesn't accept NULL.
+func (item RSSItem) categories() []string {
+	categories := []string{}
+	for _, c := range item.Categories {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+// author returns the best available author name for the item.
+func (item RSSItem) author() string {
+	if item.Creator != "" {
+		return item.Creator
+	}
+	return item.Author
+}
+
+// httpStatusError is returned by fetchFeed when the server answers with a
+// non-2xx status code.
+type httpStatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s fetching %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// fetchFeedWithRetry calls fetchFeed until it succeeds, hits a permanent
+// error or runs out of attempts, sleeping with jittered exponential backoff
+// between attempts. It returns the number of attempts made.
+func fetchFeedWithRetry(ctx context.Context, feedURL string) (*RSSFeed, int, error) {
+	var lastErr error
+	for attempt := 1; attempt <= fetchMaxAttempts; attempt++ {
+		feed, err := fetchFeed(ctx, feedURL)
+		if err == nil {
+			return feed, attempt, nil
+		}
+		lastErr = err
+		if !isTransientFetchError(err) || attempt == fetchMaxAttempts {
+			return nil, attempt, err
+		}
+		feedFetchRetries.inc()
+		backoff := fetchBackoff(attempt)
+		slog.Debug("retrying feed fetch", "feed_url", feedURL, "attempt", attempt, "backoff", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return nil, attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	return nil, fetchMaxAttempts, lastErr
+}
+
+// fetchBackoff returns a random delay in [0, min(max, base*2^(attempt-1))),
+// i.e. exponential backoff with full jitter.
+func fetchBackoff(attempt int) time.Duration {
+	ceiling := fetchBaseBackoff << (attempt - 1)
+	if ceiling <= 0 || ceiling > fetchMaxBackoff {
+		ceiling = fetchMaxBackoff
+	}
+	return rand.N(ceiling)
+}
+
+// isTransientFetchError reports whether err is worth retrying: timeouts,
+// dropped or refused connections, DNS hiccups and 5xx/408/429 responses.
+// Everything else (404, 410, malformed XML, bad URLs) is permanent.
+func isTransientFetchError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 ||
+			statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return !dnsErr.IsNotFound
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
 }
 
 func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
@@ -34,18 +173,30 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 		Timeout: 10 * time.Second,
 	}
 	req.Header.Add("User-Agent", "gator")
+	start := time.Now()
 	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
-	var feedData RSSFeed
+	slog.Debug("fetched feed", "feed_url", feedURL, "status", res.StatusCode, "duration", time.Since(start))
+	feedHTTPResponses.inc(strconv.Itoa(res.StatusCode))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &httpStatusError{StatusCode: res.StatusCode, URL: feedURL}
+	}
 	data, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
+	return parseFeed(data, feedURL)
+}
+
+func parseFeed(data []byte, feedURL string) (*RSSFeed, error) {
+	var feedData RSSFeed
 	if err := xml.Unmarshal(data, &feedData); err != nil {
-		return nil, err
+		// %v rather than %w: a parse failure is permanent even when the
+		// decoder reports it as io.EOF.
+		return nil, fmt.Errorf("couldn't parse feed %s: %v", feedURL, err)
 	}
 	feedData.Channel.Title = html.UnescapeString(feedData.Channel.Title)
 	feedData.Channel.Description = html.UnescapeString(feedData.Channel.Description)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// filterKinds are what a mute filter can match on. The matching itself
+// happens in the filter_matches SQL function.
+var filterKinds = []string{"keyword", "regex", "author", "category"}
+
+const filterUsage = "usage %s add [--feed feed_URL] <keyword|regex|author|category> <pattern...> | list | remove <filter_id> | test <post_id>"
+
+func filterHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf(filterUsage, cmd.Name)
+	}
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "add":
+		return addFilter(s, user, command{Name: cmd.Name + " add", Args: args})
+	case "list":
+		if len(args) != 0 {
+			return fmt.Errorf(filterUsage, cmd.Name)
+		}
+		return listFilters(s, user)
+	case "remove":
+		if len(args) != 1 {
+			return fmt.Errorf(filterUsage, cmd.Name)
+		}
+		return removeFilter(s, user, args[0])
+	case "test":
+		if len(args) != 1 {
+			return fmt.Errorf(filterUsage, cmd.Name)
+		}
+		return testFilters(s, user, args[0])
+	default:
+		return fmt.Errorf(filterUsage, cmd.Name)
+	}
+}
+
+func addFilter(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := fs.String("feed", "", "only mute posts from this feed")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return fmt.Errorf("usage %s [--feed feed_URL] <keyword|regex|author|category> <pattern...>", cmd.Name)
+	}
+	kind := fs.Arg(0)
+	if !slices.Contains(filterKinds, kind) {
+		return fmt.Errorf("invalid filter kind %q: use one of %s", kind, strings.Join(filterKinds, ", "))
+	}
+	pattern := strings.Join(fs.Args()[1:], " ")
+	if kind == "regex" {
+		// Check the pattern with the database's own regex engine, since an
+		// invalid one would make every browse fail.
+		if err := s.db.ValidateRegex(context.Background(), pattern); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+	}
+
+	var feedID uuid.NullUUID
+	if *feedURL != "" {
+		feed, err := lookupFeed(s, *feedURL)
+		if err != nil {
+			return err
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	filter, err := s.db.CreateFilter(context.Background(), database.CreateFilterParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		FeedID:    feedID,
+		Kind:      kind,
+		Pattern:   pattern,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create filter: %w", err)
+	}
+	fmt.Printf("Filter %s added: posts matching %s %q are now hidden\n", shortID(filter.ID), kind, pattern)
+	return nil
+}
+
+func listFilters(s *state, user database.User) error {
+	filters, err := s.db.GetFiltersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get filters: %w", err)
+	}
+	if len(filters) == 0 {
+		fmt.Println("No filters found for this user.")
+		return nil
+	}
+	fmt.Printf("Filters for user %s:\n", user.Name)
+	for _, f := range filters {
+		scope := "all feeds"
+		if f.FeedName.Valid {
+			scope = f.FeedName.String
+		}
+		fmt.Printf("* %s  %-8s %q in %s\n", shortID(f.ID), f.Kind, f.Pattern, scope)
+	}
+	return nil
+}
+
+func removeFilter(s *state, user database.User, arg string) error {
+	filters, err := s.db.GetFiltersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get filters: %w", err)
+	}
+	ids := make([]uuid.UUID, len(filters))
+	for i, f := range filters {
+		ids[i] = f.ID
+	}
+	id, err := matchIDPrefix(ids, arg, "filter")
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.DeleteFilter(context.Background(), database.DeleteFilterParams{
+		ID:     id,
+		UserID: user.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't remove filter: %w", err)
+	}
+	fmt.Printf("Filter %s has been removed\n", shortID(id))
+	return nil
+}
+
+// testFilters shows which of the user's filters hide a post.
+func testFilters(s *state, user database.User, arg string) error {
+	postID, err := resolvePostID(s, user, arg)
+	if err != nil {
+		return err
+	}
+	post, err := getPost(s, postID)
+	if err != nil {
+		return err
+	}
+	matching, err := s.db.GetMatchingFilters(context.Background(), database.GetMatchingFiltersParams{
+		UserID: user.ID,
+		ID:     postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't test filters: %w", err)
+	}
+
+	fmt.Printf("--- %s ---\n", post.Title)
+	if len(matching) == 0 {
+		fmt.Println("No filter matches this post; it is shown.")
+		return nil
+	}
+	fmt.Println("Hidden by:")
+	for _, f := range matching {
+		fmt.Printf("* %s  %-8s %q\n", shortID(f.ID), f.Kind, f.Pattern)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const folderUsage = "usage %s create <name> | rename <old_name> <new_name> | delete <name> | list"
+
+func folderHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf(folderUsage, cmd.Name)
+	}
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "create":
+		if len(args) != 1 {
+			return fmt.Errorf(folderUsage, cmd.Name)
+		}
+		return createFolder(s, user, args[0])
+	case "rename":
+		if len(args) != 2 {
+			return fmt.Errorf(folderUsage, cmd.Name)
+		}
+		return renameFolder(s, user, args[0], args[1])
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf(folderUsage, cmd.Name)
+		}
+		return deleteFolder(s, user, args[0])
+	case "list":
+		if len(args) != 0 {
+			return fmt.Errorf(folderUsage, cmd.Name)
+		}
+		return listFolders(s, user)
+	default:
+		return fmt.Errorf(folderUsage, cmd.Name)
+	}
+}
+
+func createFolder(s *state, user database.User, name string) error {
+	_, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err == nil {
+		return fmt.Errorf("folder %q already exists", name)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("couldn't look up folder: %w", err)
+	}
+	_, err = s.db.CreateFolder(context.Background(), database.CreateFolderParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      name,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create folder: %w", err)
+	}
+	fmt.Printf("Folder %s has been created\n", name)
+	return nil
+}
+
+func renameFolder(s *state, user database.User, oldName, newName string) error {
+	n, err := s.db.RenameFolder(context.Background(), database.RenameFolderParams{
+		NewName:   newName,
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      oldName,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't rename folder: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("no folder named %q", oldName)
+	}
+	fmt.Printf("Folder %s has been renamed to %s\n", oldName, newName)
+	return nil
+}
+
+func deleteFolder(s *state, user database.User, name string) error {
+	n, err := s.db.DeleteFolder(context.Background(), database.DeleteFolderParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't delete folder: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("no folder named %q", name)
+	}
+	fmt.Printf("Folder %s has been deleted; its feeds are no longer in a folder\n", name)
+	return nil
+}
+
+func listFolders(s *state, user database.User) error {
+	folders, err := s.db.GetFoldersForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get folders: %w", err)
+	}
+	if len(folders) == 0 {
+		fmt.Println("No folders found for this user.")
+		return nil
+	}
+	fmt.Printf("Folders for user %s:\n", user.Name)
+	for _, folder := range folders {
+		fmt.Printf("* %s (%d feeds)\n", folder.Name, folder.FeedCount)
+	}
+	return nil
+}
+
+// moveHandler puts a followed feed into a folder, or takes it out of its
+// folder when no folder is given.
+func moveHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage %s <feed_URL> [folder]", cmd.Name)
+	}
+	feed, err := s.db.GetFeedByURL(context.Background(), cmd.Args[0])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no feed with URL %s", cmd.Args[0])
+		}
+		return err
+	}
+
+	var folderID uuid.NullUUID
+	if len(cmd.Args) == 2 {
+		folder, err := s.db.GetFolderByName(context.Background(), database.GetFolderByNameParams{
+			UserID: user.ID,
+			Name:   cmd.Args[1],
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no folder named %q, create it with: folder create %s", cmd.Args[1], cmd.Args[1])
+			}
+			return fmt.Errorf("couldn't look up folder: %w", err)
+		}
+		folderID = uuid.NullUUID{UUID: folder.ID, Valid: true}
+	}
+
+	n, err := s.db.SetFeedFollowFolder(context.Background(), database.SetFeedFollowFolderParams{
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+		FolderID:  folderID,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move feed: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("you don't follow %s", feed.Url)
+	}
+	if folderID.Valid {
+		fmt.Printf("Moved %s to folder %s\n", feed.Name, cmd.Args[1])
+	} else {
+		fmt.Printf("Moved %s out of its folder\n", feed.Name)
+	}
+	return nil
+}
@@ -2,11 +2,7 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"log"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -85,76 +81,6 @@ func getUsersHandler(state *state, cmd command) error {
 	return nil
 }
 
-func aggHandler(state *state, cmd command) error {
-	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
-		return fmt.Errorf("usage: %v <time_between_reqs>", cmd.Name)
-	}
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
-	if err != nil {
-		return fmt.Errorf("invalid duration: %w", err)
-	}
-	log.Printf("Collecting feeds every %s...", timeBetweenRequests)
-	ticker := time.NewTicker(timeBetweenRequests)
-	for ; ; <-ticker.C {
-		scrapeFeeds(state)
-	}
-}
-
-func scrapeFeeds(s *state) {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		log.Println("Couldn't get next feeds to fetch", err)
-		return
-	}
-	log.Println("Found a feed to fetch!")
-	scrapeFeed(s.db, feed)
-}
-
-func scrapeFeed(db *database.Queries, feed database.Feed) {
-	_, err := db.MarkFeedFetched(context.Background(), feed.ID)
-	if err != nil {
-		log.Printf("Couldn't mark feed %s fetched: %v", feed.Name, err)
-		return
-	}
-
-	feedData, err := fetchFeed(context.Background(), feed.Url)
-	if err != nil {
-		log.Printf("Couldn't collect feed %s: %v", feed.Name, err)
-		return
-	}
-	for _, item := range feedData.Channel.Item {
-		publishedAt := sql.NullTime{}
-		if t, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
-			publishedAt = sql.NullTime{
-				Time:  t,
-				Valid: true,
-			}
-		}
-
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-			FeedID:    feed.ID,
-			Title:     item.Title,
-			Description: sql.NullString{
-				String: item.Description,
-				Valid:  true,
-			},
-			Url:         item.Link,
-			PublishedAt: publishedAt,
-		})
-		if err != nil {
-			if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-				continue
-			}
-			log.Printf("Couldn't create post: %v", err)
-			continue
-		}
-	}
-	log.Printf("Feed %s collected, %v posts found", feed.Name, len(feedData.Channel.Item))
-}
-
 func addFeedHandler(state *state, cmd command, user database.User) error {
 	if len(cmd.Args) != 2 {
 		return fmt.Errorf("usage %s <name> <feedURL>", cmd.Name)
@@ -264,8 +190,39 @@ func followingHandler(s *state, cmd command, user database.User) error {
 	}
 
 	fmt.Printf("Feed follows for user %s:\n", user.Name)
+	// Feeds outside any folder come first, then each folder in turn.
+	folder := ""
 	for _, ff := range feedFollows {
-		fmt.Printf("* %s\n", ff.FeedName)
+		name := ff.FeedName
+		if ff.DisplayName.Valid {
+			name = fmt.Sprintf("%s (%s)", ff.DisplayName.String, ff.FeedName)
+		}
+		name = feedLabel(name, ff.Color, ff.Emoji)
+		if !ff.FolderName.Valid {
+			fmt.Printf("* %s\n", name)
+			continue
+		}
+		if ff.FolderName.String != folder {
+			folder = ff.FolderName.String
+			fmt.Printf("%s/\n", folder)
+		}
+		fmt.Printf("  * %s\n", name)
+	}
+
+	// Saved searches are listed like feeds, so they can be browsed the same way.
+	searches, err := s.db.GetSavedSearchesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get saved searches: %w", err)
+	}
+	if len(searches) > 0 {
+		fmt.Println("Saved searches:")
+	}
+	for _, search := range searches {
+		unread, err := savedSearchUnread(s, user, search)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  * %s (%d unread)\n", search.Name, unread)
 	}
 
 	return nil
@@ -292,36 +249,6 @@ func unFollowHandler(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-func browseHandler(s *state, cmd command, user database.User) error {
-	limit := 2
-	if len(cmd.Args) == 1 {
-		if specifiedLimit, err := strconv.Atoi(cmd.Args[0]); err == nil {
-			limit = specifiedLimit
-		} else {
-			return fmt.Errorf("invalid limit: %w", err)
-		}
-	}
-
-	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
-	})
-	if err != nil {
-		return fmt.Errorf("couldn't get posts for user: %w", err)
-	}
-
-	fmt.Printf("Found %d posts for user %s:\n", len(posts), user.Name)
-	for _, post := range posts {
-		fmt.Printf("%s from %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName)
-		fmt.Printf("--- %s ---\n", post.Title)
-		fmt.Printf("    %v\n", post.Description.String)
-		fmt.Printf("Link: %s\n", post.Url)
-		fmt.Println("=====================================")
-	}
-
-	return nil
-}
-
 func printFeedFollow(username, feedname string) {
 	fmt.Printf("* User:          %s\n", username)
 	fmt.Printf("* Feed:          %s\n", feedname)
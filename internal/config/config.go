@@ -12,6 +12,43 @@ const configFileName = ".gatorconfig.json"
 type Config struct {
   DBURL string `json:"db_url"`
   CurrentUserName string `json:"current_user_name"`
+  // WebSub push is enabled in agg mode when both of these are set. The
+  // callback URL is the public base URL hubs use to reach the listen address.
+  WebSubListenAddr string `json:"websub_listen_addr,omitempty"`
+  WebSubCallbackURL string `json:"websub_callback_url,omitempty"`
+  // MetricsAddr, when set, makes agg serve Prometheus metrics on /metrics.
+  MetricsAddr string `json:"metrics_addr,omitempty"`
+  // LogFormat is "text" (the default) or "json"; LogLevel is one of debug,
+  // info, warn or error.
+  LogFormat string `json:"log_format,omitempty"`
+  LogLevel string `json:"log_level,omitempty"`
+  // StatusAddr is where agg serves /healthz and /status and where the
+  // status command looks for it: host:port or unix:/path/to/socket.
+  StatusAddr string `json:"status_addr,omitempty"`
+  // Jobs maps maintenance job names (purge, digest, deadlinks,
+  // vacuumstats) to cron expressions run by the leading agg instance.
+  Jobs map[string]string `json:"jobs,omitempty"`
+  // PostRetentionDays and PostRetentionPosts are the default limits the
+  // purge job and command apply to every feed: posts older than that many
+  // days, or beyond that many of a feed's newest posts, are deleted. Feeds
+  // can override either one.
+  PostRetentionDays int `json:"post_retention_days,omitempty"`
+  PostRetentionPosts int `json:"post_retention_posts,omitempty"`
+  // ArchiveDir, when set, makes purge write the posts it deletes there as
+  // gzipped JSON lines.
+  ArchiveDir string `json:"archive_dir,omitempty"`
+  DigestDir string `json:"digest_dir,omitempty"`
+  // AdminUsers may rename, move and delete feeds added by other users.
+  AdminUsers []string `json:"admin_users,omitempty"`
+}
+
+func (cfg *Config) IsAdmin (userName string) bool {
+  for _, admin := range cfg.AdminUsers {
+    if admin == userName {
+      return true
+    }
+  }
+  return false
 }
 
 func getFilePath () (string, error) {
@@ -11,12 +11,199 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const browsePosts = `-- name: BrowsePosts :many
+
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.search_vector, posts.author, posts.categories, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, feeds.url AS feed_url,
+feed_follows.color AS feed_color, feed_follows.emoji AS feed_emoji,
+EXISTS (
+    SELECT 1 FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+) AS read,
+EXISTS (
+    SELECT 1 FROM saved_posts
+    WHERE saved_posts.post_id = posts.id AND saved_posts.user_id = $1
+) AS saved,
+ARRAY(
+    SELECT tags.name FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = $1
+    ORDER BY tags.name
+)::text[] AS tags,
+(CASE WHEN $2::text = 'fetched' THEN posts.created_at
+    ELSE COALESCE(posts.published_at, posts.created_at) END)::timestamp AS sort_time
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND browse_filter_matches($1, posts, feed_follows,
+    $3::uuid[], $4::text,
+    $5::timestamp, $6::timestamp,
+    $7::text, $8::text, $9::text)
+AND (NOT $10::bool OR NOT EXISTS (
+    SELECT 1 FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+))
+AND (NOT $11::bool OR EXISTS (
+    SELECT 1 FROM saved_posts
+    WHERE saved_posts.post_id = posts.id AND saved_posts.user_id = $1
+))
+AND ($12::timestamp IS NULL OR (
+    CASE WHEN $2 = 'feed' THEN
+        COALESCE(feed_follows.display_name, feeds.name) > $13::text
+        OR (COALESCE(feed_follows.display_name, feeds.name) = $13
+            AND (COALESCE(posts.published_at, posts.created_at), posts.id)
+                < ($12, $14::uuid))
+    ELSE
+        ((CASE WHEN $2 = 'fetched' THEN posts.created_at
+            ELSE COALESCE(posts.published_at, posts.created_at) END), posts.id)
+            < ($12, $14::uuid)
+    END
+))
+ORDER BY
+    CASE WHEN $2 = 'feed' THEN COALESCE(feed_follows.display_name, feeds.name) END ASC,
+    sort_time DESC,
+    posts.id DESC
+LIMIT $15
+`
+
+type BrowsePostsParams struct {
+	UserID     uuid.UUID
+	Sort       string
+	FeedIds    []uuid.UUID
+	Folder     sql.NullString
+	Since      sql.NullTime
+	Until      sql.NullTime
+	Author     sql.NullString
+	Keyword    sql.NullString
+	Tag        sql.NullString
+	UnreadOnly bool
+	SavedOnly  bool
+	AfterTime  sql.NullTime
+	AfterFeed  sql.NullString
+	AfterID    uuid.NullUUID
+	Limit      int32
+}
+
+type BrowsePostsRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Content      sql.NullString
+	SearchVector interface{}
+	Author       sql.NullString
+	Categories   []string
+	FeedName     string
+	FeedUrl      string
+	FeedColor    sql.NullString
+	FeedEmoji    sql.NullString
+	Read         bool
+	Saved        bool
+	Tags         []string
+	SortTime     time.Time
+}
+
+func (q *Queries) BrowsePosts(ctx context.Context, arg BrowsePostsParams) ([]BrowsePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, browsePosts,
+		arg.UserID,
+		arg.Sort,
+		pq.Array(arg.FeedIds),
+		arg.Folder,
+		arg.Since,
+		arg.Until,
+		arg.Author,
+		arg.Keyword,
+		arg.Tag,
+		arg.UnreadOnly,
+		arg.SavedOnly,
+		arg.AfterTime,
+		arg.AfterFeed,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BrowsePostsRow
+	for rows.Next() {
+		var i BrowsePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Content,
+			&i.SearchVector,
+			&i.Author,
+			pq.Array(&i.Categories),
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedColor,
+			&i.FeedEmoji,
+			&i.Read,
+			&i.Saved,
+			pq.Array(&i.Tags),
+			&i.SortTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countFeedsDueForFetch = `-- name: CountFeedsDueForFetch :one
+SELECT COUNT(*) FROM feeds
+WHERE status = 'active'
+AND (last_fetched_at IS NULL OR last_fetched_at < NOW() - make_interval(secs => $1::float8))
+`
+
+func (q *Queries) CountFeedsDueForFetch(ctx context.Context, minAgeSeconds float64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeedsDueForFetch, minAgeSeconds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOtherFeedFollowers = `-- name: CountOtherFeedFollowers :one
+SELECT COUNT(*) FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2
+`
+
+type CountOtherFeedFollowersParams struct {
+	FeedID uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) CountOtherFeedFollowers(ctx context.Context, arg CountOtherFeedFollowersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOtherFeedFollowers, arg.FeedID, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
 `
 
 type CreateFeedParams struct {
@@ -46,6 +233,16 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
 	)
 	return i, err
 }
@@ -54,10 +251,10 @@ const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    RETURNING id, created_at, updated_at, user_id, feed_id, folder_id, display_name, color, emoji
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+    inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder_id, inserted_feed_follow.display_name, inserted_feed_follow.color, inserted_feed_follow.emoji,
     feeds.name AS feed_name,
     users.name AS user_name
 FROM inserted_feed_follow
@@ -74,13 +271,17 @@ type CreateFeedFollowParams struct {
 }
 
 type CreateFeedFollowRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	DisplayName sql.NullString
+	Color       sql.NullString
+	Emoji       sql.NullString
+	FeedName    string
+	UserName    string
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
@@ -98,56 +299,29 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.FolderID,
+		&i.DisplayName,
+		&i.Color,
+		&i.Emoji,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const deleteFeed = `-- name: DeleteFeed :execrows
+DELETE FROM feeds WHERE id = $1
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-}
-
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
-	)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Title,
-		&i.Url,
-		&i.Description,
-		&i.PublishedAt,
-		&i.FeedID,
-	)
-	return i, err
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
-
 delete from feed_follows where user_id=$1 and feed_id=$2
 `
 
@@ -161,9 +335,37 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 	return err
 }
 
-const getFeedByURL = `-- name: GetFeedByURL :one
+const getFeedByID = `-- name: GetFeedByID :one
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since from feeds where id=$1
+`
 
-select id, created_at, updated_at, name, url, user_id, last_fetched_at from feeds where url=$1
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const getFeedByURL = `-- name: GetFeedByURL :one
+select id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since from feeds where url=$1
 `
 
 func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
@@ -177,26 +379,46 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
 	)
 	return i, err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feeds.name AS feed_name, users.name AS user_name
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder_id, feed_follows.display_name, feed_follows.color, feed_follows.emoji, feeds.name AS feed_name, feeds.url AS feed_url, feeds.site_url,
+users.name AS user_name, folders.name AS folder_name
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feeds.user_id = users.id
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
 WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS FIRST, COALESCE(feed_follows.display_name, feeds.name)
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	FeedName  string
-	UserName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	DisplayName sql.NullString
+	Color       sql.NullString
+	Emoji       sql.NullString
+	FeedName    string
+	FeedUrl     string
+	SiteUrl     sql.NullString
+	UserName    string
+	FolderName  sql.NullString
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -214,8 +436,80 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.FolderID,
+			&i.DisplayName,
+			&i.Color,
+			&i.Emoji,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.SiteUrl,
 			&i.UserName,
+			&i.FolderName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedStats = `-- name: GetFeedStats :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = $1) AS posts,
+    (SELECT MAX(posts.created_at) FROM posts WHERE posts.feed_id = $1)::timestamp AS latest_post_at
+`
+
+type GetFeedStatsRow struct {
+	Followers    int64
+	Posts        int64
+	LatestPostAt sql.NullTime
+}
+
+func (q *Queries) GetFeedStats(ctx context.Context, feedID uuid.UUID) (GetFeedStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedStats, feedID)
+	var i GetFeedStatsRow
+	err := row.Scan(&i.Followers, &i.Posts, &i.LatestPostAt)
+	return i, err
+}
+
+const getFeedsByIDs = `-- name: GetFeedsByIDs :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since FROM feeds WHERE id = ANY($1::uuid[]) ORDER BY name
+`
+
+func (q *Queries) GetFeedsByIDs(ctx context.Context, ids []uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Status,
+			&i.LastError,
+			&i.LastStatusCode,
+			&i.FailingSince,
+			&i.ClaimedBy,
+			&i.ClaimedUntil,
+			&i.SiteUrl,
+			&i.RetainDays,
+			&i.RetainPosts,
+			&i.NotFoundSince,
 		); err != nil {
 			return nil, err
 		}
@@ -230,77 +524,254 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+const getFeedsDueForFetch = `-- name: GetFeedsDueForFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since FROM feeds
+WHERE status = 'active'
+AND (last_fetched_at IS NULL OR last_fetched_at < NOW() - make_interval(secs => $1::float8))
 ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
+func (q *Queries) GetFeedsDueForFetch(ctx context.Context, minAgeSeconds float64) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsDueForFetch, minAgeSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Status,
+			&i.LastError,
+			&i.LastStatusCode,
+			&i.FailingSince,
+			&i.ClaimedBy,
+			&i.ClaimedUntil,
+			&i.SiteUrl,
+			&i.RetainDays,
+			&i.RetainPosts,
+			&i.NotFoundSince,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedsNeedingAttention = `-- name: GetFeedsNeedingAttention :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.status, feeds.last_error, feeds.last_status_code, feeds.failing_since, feeds.claimed_by, feeds.claimed_until, feeds.site_url, feeds.retain_days, feeds.retain_posts, feeds.not_found_since FROM feeds
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND (feeds.status <> 'active' OR feeds.failing_since IS NOT NULL)
+ORDER BY feeds.status DESC, feeds.failing_since ASC
+`
+
+func (q *Queries) GetFeedsNeedingAttention(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsNeedingAttention, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Status,
+			&i.LastError,
+			&i.LastStatusCode,
+			&i.FailingSince,
+			&i.ClaimedBy,
+			&i.ClaimedUntil,
+			&i.SiteUrl,
+			&i.RetainDays,
+			&i.RetainPosts,
+			&i.NotFoundSince,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedIDsByName = `-- name: GetFollowedFeedIDsByName :many
+SELECT feeds.id FROM feeds
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND $2::text IN (feeds.name, feeds.url, feed_follows.display_name)
+ORDER BY feeds.name
+`
+
+type GetFollowedFeedIDsByNameParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+// Feeds are matched by name, URL or the name the user gave them, like
+// browse --feed does.
+func (q *Queries) GetFollowedFeedIDsByName(ctx context.Context, arg GetFollowedFeedIDsByNameParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedIDsByName, arg.UserID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.content, posts.search_vector, posts.author, posts.categories, feeds.name AS feed_name
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = $1
+`
+
+type GetPostByIDRow struct {
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Content      sql.NullString
+	SearchVector interface{}
+	Author       sql.NullString
+	Categories   []string
+	FeedName     string
+}
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (GetPostByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i GetPostByIDRow
 	err := row.Scan(
 		&i.ID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Name,
+		&i.Title,
 		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Content,
+		&i.SearchVector,
+		&i.Author,
+		pq.Array(&i.Categories),
+		&i.FeedName,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-
-SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name FROM posts
+const getPostIDsByPrefix = `-- name: GetPostIDsByPrefix :many
+SELECT posts.id FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
-JOIN feeds ON posts.feed_id = feeds.id
 WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2
+AND posts.id BETWEEN $2::uuid AND $3::uuid
+ORDER BY posts.id
+LIMIT 2
 `
 
-type GetPostsForUserParams struct {
+type GetPostIDsByPrefixParams struct {
 	UserID uuid.UUID
-	Limit  int32
+	Low    uuid.UUID
+	High   uuid.UUID
 }
 
-type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
-	FeedName    string
+// A prefix is looked up as the range of IDs that start with it, so the
+// primary key index can be used. Only posts in feeds the user follows count.
+func (q *Queries) GetPostIDsByPrefix(ctx context.Context, arg GetPostIDsByPrefixParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostIDsByPrefix, arg.UserID, arg.Low, arg.High)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+const getPostIDsInRanges = `-- name: GetPostIDsInRanges :many
+SELECT posts.id FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN unnest($1::uuid[], $2::uuid[]) AS ranges (low, high)
+ON posts.id BETWEEN ranges.low AND ranges.high
+WHERE feed_follows.user_id = $3
+ORDER BY posts.id
+`
+
+type GetPostIDsInRangesParams struct {
+	Lows   []uuid.UUID
+	Highs  []uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetPostIDsInRanges(ctx context.Context, arg GetPostIDsInRangesParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostIDsInRanges, pq.Array(arg.Lows), pq.Array(arg.Highs), arg.UserID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetPostsForUserRow
+	var items []uuid.UUID
 	for rows.Next() {
-		var i GetPostsForUserRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Title,
-			&i.Url,
-			&i.Description,
-			&i.PublishedAt,
-			&i.FeedID,
-			&i.FeedName,
-		); err != nil {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -350,7 +821,7 @@ update feeds
 set last_fetched_at = NOW(),
 updated_at = NOW()
 where id=$1
-returning id, created_at, updated_at, name, url, user_id, last_fetched_at
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
 `
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -364,6 +835,376 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :one
+update feeds
+set last_error = $2,
+last_status_code = $3,
+failing_since = COALESCE(failing_since, $4),
+not_found_since = CASE
+    WHEN $3 = 404 THEN COALESCE(not_found_since, $4)
+    WHEN $3 IS NULL THEN not_found_since
+    ELSE NULL
+END,
+updated_at = NOW()
+where id=$1
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
+`
+
+type RecordFeedFailureParams struct {
+	ID             uuid.UUID
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
+	FailingSince   sql.NullTime
+}
+
+// A failure without an HTTP status, such as a timeout, leaves a run of 404s
+// unbroken; any other status ends it.
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedFailure,
+		arg.ID,
+		arg.LastError,
+		arg.LastStatusCode,
+		arg.FailingSince,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+update feeds
+set last_error = NULL,
+last_status_code = NULL,
+failing_since = NULL,
+not_found_since = NULL,
+site_url = $2
+where id=$1
+`
+
+type RecordFeedSuccessParams struct {
+	ID      uuid.UUID
+	SiteUrl sql.NullString
+}
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess, arg.ID, arg.SiteUrl)
+	return err
+}
+
+const renameFeed = `-- name: RenameFeed :one
+UPDATE feeds
+SET name = $2, updated_at = $3
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
+`
+
+type RenameFeedParams struct {
+	ID        uuid.UUID
+	Name      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, renameFeed, arg.ID, arg.Name, arg.UpdatedAt)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const setFeedFollowDisplay = `-- name: SetFeedFollowDisplay :execrows
+
+UPDATE feed_follows
+SET display_name = $3, color = $4, emoji = $5, updated_at = $6
+WHERE user_id = $1 AND feed_id = $2
+`
+
+type SetFeedFollowDisplayParams struct {
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	DisplayName sql.NullString
+	Color       sql.NullString
+	Emoji       sql.NullString
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) SetFeedFollowDisplay(ctx context.Context, arg SetFeedFollowDisplayParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowDisplay,
+		arg.UserID,
+		arg.FeedID,
+		arg.DisplayName,
+		arg.Color,
+		arg.Emoji,
+		arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :one
+UPDATE feeds
+SET retain_days = $2, retain_posts = $3, updated_at = $4
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
+`
+
+type SetFeedRetentionParams struct {
+	ID          uuid.UUID
+	RetainDays  sql.NullInt32
+	RetainPosts sql.NullInt32
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedRetention,
+		arg.ID,
+		arg.RetainDays,
+		arg.RetainPosts,
+		arg.UpdatedAt,
+	)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
 	)
 	return i, err
 }
+
+const setFeedStatus = `-- name: SetFeedStatus :one
+update feeds
+set status = $2,
+updated_at = NOW()
+where id=$1
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
+`
+
+type SetFeedStatusParams struct {
+	ID     uuid.UUID
+	Status string
+}
+
+func (q *Queries) SetFeedStatus(ctx context.Context, arg SetFeedStatusParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedStatus, arg.ID, arg.Status)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const setFeedURL = `-- name: SetFeedURL :one
+UPDATE feeds
+SET url = $2,
+updated_at = $3,
+status = 'active',
+last_error = NULL,
+last_status_code = NULL,
+failing_since = NULL,
+not_found_since = NULL,
+site_url = NULL
+WHERE id = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, status, last_error, last_status_code, failing_since, claimed_by, claimed_until, site_url, retain_days, retain_posts, not_found_since
+`
+
+type SetFeedURLParams struct {
+	ID        uuid.UUID
+	Url       string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) SetFeedURL(ctx context.Context, arg SetFeedURLParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, setFeedURL, arg.ID, arg.Url, arg.UpdatedAt)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Status,
+		&i.LastError,
+		&i.LastStatusCode,
+		&i.FailingSince,
+		&i.ClaimedBy,
+		&i.ClaimedUntil,
+		&i.SiteUrl,
+		&i.RetainDays,
+		&i.RetainPosts,
+		&i.NotFoundSince,
+	)
+	return i, err
+}
+
+const upsertFeedFollow = `-- name: UpsertFeedFollow :one
+
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET folder_id = EXCLUDED.folder_id,
+updated_at = EXCLUDED.updated_at
+WHERE EXCLUDED.folder_id IS NOT NULL
+AND feed_follows.folder_id IS DISTINCT FROM EXCLUDED.folder_id
+RETURNING (xmax = 0) AS inserted
+`
+
+type UpsertFeedFollowParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.UUID
+	FolderID  uuid.NullUUID
+}
+
+// Following an already followed feed only moves it into folder_id, when one
+// is given. No row comes back when nothing changed.
+func (q *Queries) UpsertFeedFollow(ctx context.Context, arg UpsertFeedFollowParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeedFollow,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.FeedID,
+		arg.FolderID,
+	)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (url) DO UPDATE
+SET title = EXCLUDED.title,
+description = EXCLUDED.description,
+published_at = EXCLUDED.published_at,
+content = EXCLUDED.content,
+author = EXCLUDED.author,
+categories = EXCLUDED.categories,
+updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+OR posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+OR posts.content IS DISTINCT FROM EXCLUDED.content
+OR posts.author IS DISTINCT FROM EXCLUDED.author
+OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted
+`
+
+type UpsertPostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Content     sql.NullString
+	Author      sql.NullString
+	Categories  []string
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Content,
+		arg.Author,
+		pq.Array(arg.Categories),
+	)
+	var inserted bool
+	err := row.Scan(&inserted)
+	return inserted, err
+}
@@ -11,33 +11,155 @@ import (
 	"github.com/google/uuid"
 )
 
+type AggInstance struct {
+	ID          uuid.UUID
+	Hostname    string
+	Pid         int32
+	StartedAt   time.Time
+	HeartbeatAt time.Time
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	Url            string
+	UserID         uuid.UUID
+	LastFetchedAt  sql.NullTime
+	Status         string
+	LastError      sql.NullString
+	LastStatusCode sql.NullInt32
+	FailingSince   sql.NullTime
+	ClaimedBy      uuid.NullUUID
+	ClaimedUntil   sql.NullTime
+	SiteUrl        sql.NullString
+	RetainDays     sql.NullInt32
+	RetainPosts    sql.NullInt32
+	NotFoundSince  sql.NullTime
 }
 
 type FeedFollow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	FolderID    uuid.NullUUID
+	DisplayName sql.NullString
+	Color       sql.NullString
+	Emoji       sql.NullString
+}
+
+type Filter struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    uuid.NullUUID
+	Kind      string
+	Pattern   string
+}
+
+type Folder struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	UserID    uuid.UUID
-	FeedID    uuid.UUID
+	Name      string
+}
+
+type Job struct {
+	Name           string
+	Schedule       string
+	NextRunAt      sql.NullTime
+	LastRunAt      sql.NullTime
+	LastDurationMs sql.NullInt64
+	LastStatus     sql.NullString
+	LastResult     sql.NullString
+}
+
+type Lease struct {
+	Name      string
+	Holder    uuid.UUID
+	ExpiresAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	RuleID    uuid.NullUUID
+	SeenAt    sql.NullTime
 }
 
 type Post struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt sql.NullTime
-	FeedID      uuid.UUID
+	ID           uuid.UUID
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  sql.NullTime
+	FeedID       uuid.UUID
+	Content      sql.NullString
+	SearchVector interface{}
+	Author       sql.NullString
+	Categories   []string
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostTag struct {
+	PostID    uuid.UUID
+	TagID     uuid.UUID
+	CreatedAt time.Time
+}
+
+type Rule struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UserID         uuid.UUID
+	FeedID         uuid.NullUUID
+	TitlePattern   sql.NullString
+	ContentPattern sql.NullString
+	TagID          uuid.NullUUID
+	Star           bool
+	MarkRead       bool
+	Notify         bool
+}
+
+type SavedPost struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	CreatedAt time.Time
+	Note      sql.NullString
+}
+
+type SavedSearch struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	Keyword   sql.NullString
+	Author    sql.NullString
+	Folder    sql.NullString
+	Tag       sql.NullString
+	Since     sql.NullString
+	FeedIds   []uuid.UUID
+	Until     sql.NullString
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
 }
 
 type User struct {
@@ -46,3 +168,15 @@ type User struct {
 	UpdatedAt time.Time
 	Name      string
 }
+
+type WebsubSubscription struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	FeedID         uuid.UUID
+	HubUrl         string
+	TopicUrl       string
+	Secret         string
+	Status         string
+	LeaseExpiresAt sql.NullTime
+}
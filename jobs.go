@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	deadLinkSample  = 200
+	deadLinkWorkers = 8
+	digestWindow    = 24 * time.Hour
+)
+
+// purgeJob deletes posts past their feed's retention, archiving them to
+// archive_dir first when it is set.
+func purgeJob(ctx context.Context, s *state) (string, error) {
+	result, err := purgePosts(ctx, s, purgeOptions{archiveDir: s.cfg.ArchiveDir})
+	if err != nil {
+		return "", err
+	}
+	msg := fmt.Sprintf("deleted %d posts from %d feeds", result.deleted, len(result.byFeed))
+	if result.archive != "" {
+		msg += ", archived to " + result.archive
+	}
+	return msg, nil
+}
+
+// digestJob writes a plain text digest of the last day's posts for every
+// user into digest_dir.
+func digestJob(ctx context.Context, s *state) (string, error) {
+	if s.cfg.DigestDir == "" {
+		return "", errors.New("digest_dir is not set in the config")
+	}
+	if err := os.MkdirAll(s.cfg.DigestDir, 0o755); err != nil {
+		return "", err
+	}
+	users, err := s.db.GetUsers(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	written := 0
+	for _, user := range users {
+		posts, err := s.db.GetPostsForDigest(ctx, database.GetPostsForDigestParams{
+			UserID:    user.ID,
+			CreatedAt: now.UTC().Add(-digestWindow),
+		})
+		if err != nil {
+			return "", fmt.Errorf("couldn't get posts for %s: %w", user.Name, err)
+		}
+		if len(posts) == 0 {
+			continue
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Digest for %s, %s\n", user.Name, now.Format("Mon Jan 2 2006"))
+		lastFeed := ""
+		for _, post := range posts {
+			if post.FeedName != lastFeed {
+				fmt.Fprintf(&b, "\n== %s ==\n", post.FeedName)
+				lastFeed = post.FeedName
+			}
+			fmt.Fprintf(&b, "* %s\n  %s\n", post.Title, post.Url)
+		}
+		name := fmt.Sprintf("digest-%s-%s.txt", strings.ReplaceAll(user.Name, string(filepath.Separator), "_"), now.Format(time.DateOnly))
+		if err := os.WriteFile(filepath.Join(s.cfg.DigestDir, name), []byte(b.String()), 0o644); err != nil {
+			return "", err
+		}
+		written++
+	}
+	return fmt.Sprintf("wrote %d digests to %s", written, s.cfg.DigestDir), nil
+}
+
+// deadLinksJob checks that the most recent posts' links still resolve.
+func deadLinksJob(ctx context.Context, s *state) (string, error) {
+	posts, err := s.db.GetRecentPostURLs(ctx, deadLinkSample)
+	if err != nil {
+		return "", err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	urls := make(chan string)
+	var mu sync.Mutex
+	var broken []string
+	var wg sync.WaitGroup
+	for range deadLinkWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range urls {
+				if !linkAlive(ctx, client, url) {
+					mu.Lock()
+					broken = append(broken, url)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, post := range posts {
+		urls <- post.Url
+	}
+	close(urls)
+	wg.Wait()
+
+	result := fmt.Sprintf("checked %d links, %d broken", len(posts), len(broken))
+	if len(broken) > 0 {
+		shown := broken[:min(len(broken), 5)]
+		result += ": " + strings.Join(shown, ", ")
+	}
+	return result, nil
+}
+
+func linkAlive(ctx context.Context, client *http.Client, url string) bool {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return false
+		}
+		req.Header.Add("User-Agent", "gator")
+		res, err := client.Do(req)
+		if err != nil {
+			return false
+		}
+		res.Body.Close()
+		// Some servers don't implement HEAD; give them a GET before
+		// calling the link dead.
+		if res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented {
+			continue
+		}
+		return res.StatusCode < 400
+	}
+	return false
+}
+
+// vacuumStatsJob summarises dead tuples and the last vacuum per table.
+func vacuumStatsJob(ctx context.Context, s *state) (string, error) {
+	stats, err := s.db.GetTableVacuumStats(ctx)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, table := range stats {
+		part := fmt.Sprintf("%s %d live/%d dead", table.TableName, table.LiveTuples, table.DeadTuples)
+		if table.LastVacuumed.Valid {
+			part += ", vacuumed " + table.LastVacuumed.Time.Format(time.DateTime)
+		} else {
+			part += ", never vacuumed"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; "), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/sajidcodess/gator/internal/config"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// newLogger builds the process-wide logger from the config's log_format and
+// log_level, with --verbose and --quiet taking precedence over the level.
+func newLogger(cfg *config.Config, verbose, quiet bool) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if cfg.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+			return nil, fmt.Errorf("invalid log_level %q: %w", cfg.LogLevel, err)
+		}
+	}
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelWarn
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.LogFormat) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log_format %q: want text or json", cfg.LogFormat)
+	}
+}
+
+// feedLogger returns a logger that tags every record with the feed.
+func feedLogger(feed database.Feed) *slog.Logger {
+	return slog.With("feed_id", feed.ID, "feed_url", feed.Url, "feed_name", feed.Name)
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 
 	_ "github.com/lib/pq"
@@ -12,8 +14,12 @@ import (
 )
 
 type state struct {
-	cfg *config.Config
-	db  *database.Queries
+	cfg      *config.Config
+	db       *database.Queries
+	conn     *sql.DB
+	webSub   *webSubscriber
+	instance *aggInstance
+	status   *aggStatus
 }
 
 func main() {
@@ -22,15 +28,30 @@ func main() {
 		log.Fatalf("Error reading the config: %v", err)
 	}
 
+	flags := flag.NewFlagSet("gator", flag.ExitOnError)
+	var verbose, quiet bool
+	flags.BoolVar(&verbose, "verbose", false, "log debug messages")
+	flags.BoolVar(&verbose, "v", false, "shorthand for --verbose")
+	flags.BoolVar(&quiet, "quiet", false, "only log warnings and errors")
+	flags.BoolVar(&quiet, "q", false, "shorthand for --quiet")
+	flags.Parse(os.Args[1:])
+
+	logger, err := newLogger(&cfg, verbose, quiet)
+	if err != nil {
+		log.Fatalf("Error configuring logging: %v", err)
+	}
+	slog.SetDefault(logger)
+
 	db, err := sql.Open("postgres", cfg.DBURL)
 	if err != nil {
 		log.Fatalf("Error while opening DB connection: %s", err)
 	}
-	dbQueries := database.New(db)
+	dbQueries := database.New(instrumentedDB{db: db})
 
 	programState := &state{
-		cfg: &cfg,
-		db:  dbQueries,
+		cfg:  &cfg,
+		db:   dbQueries,
+		conn: db,
 	}
 
 	cmds := commands{
@@ -41,23 +62,53 @@ func main() {
 	cmds.register("reset", resetHandler)
 	cmds.register("users", getUsersHandler)
 	cmds.register("agg", aggHandler)
+	cmds.register("fetch", fetchHandler)
+	cmds.register("status", statusHandler)
+	cmds.register("jobs", jobsHandler)
+	cmds.register("purge", purgeHandler)
 	cmds.register("addfeed", middlewareLoggedIn(addFeedHandler))
 	cmds.register("feeds", middlewareLoggedIn(listFeeds))
+	cmds.register("feed", middlewareLoggedIn(feedHandler))
 	cmds.register("follow", middlewareLoggedIn(followHandler))
 	cmds.register("unfollow", middlewareLoggedIn(unFollowHandler))
 	cmds.register("following", middlewareLoggedIn(followingHandler))
+	cmds.register("import", middlewareLoggedIn(importHandler))
+	cmds.register("export", middlewareLoggedIn(exportHandler))
+	cmds.register("folder", middlewareLoggedIn(folderHandler))
+	cmds.register("move", middlewareLoggedIn(moveHandler))
+	cmds.register("alias", middlewareLoggedIn(aliasHandler))
+	cmds.register("filter", middlewareLoggedIn(filterHandler))
+	cmds.register("rule", middlewareLoggedIn(ruleHandler))
+	cmds.register("notifications", middlewareLoggedIn(notificationsHandler))
 	cmds.register("browse", middlewareLoggedIn(browseHandler))
+	cmds.register("open", middlewareLoggedIn(openHandler))
+	cmds.register("read", middlewareLoggedIn(readHandler))
+	cmds.register("unread", middlewareLoggedIn(unreadHandler))
+	cmds.register("markallread", middlewareLoggedIn(markAllReadHandler))
+	cmds.register("save", middlewareLoggedIn(saveHandler))
+	cmds.register("unsave", middlewareLoggedIn(unsaveHandler))
+	cmds.register("saved", middlewareLoggedIn(savedHandler))
+	cmds.register("tag", middlewareLoggedIn(tagHandler))
+	cmds.register("untag", middlewareLoggedIn(untagHandler))
+	cmds.register("tags", middlewareLoggedIn(tagsHandler))
+	cmds.register("search", middlewareLoggedIn(searchHandler))
+	cmds.register("savedsearch", middlewareLoggedIn(savedSearchHandler))
+	cmds.register("tui", middlewareLoggedIn(tuiHandler))
+	cmds.register("attention", middlewareLoggedIn(attentionHandler))
+	cmds.register("pausefeed", middlewareLoggedIn(pauseFeedHandler))
+	cmds.register("resumefeed", middlewareLoggedIn(resumeFeedHandler))
 
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: cli <command> [args...]")
+	if flags.NArg() < 1 {
+		fmt.Println("Usage: cli [--verbose|--quiet] <command> [args...]")
 		return
 	}
 
-	cmdName := os.Args[1]
-	cmdArgs := os.Args[2:]
+	cmdName := flags.Arg(0)
+	cmdArgs := flags.Args()[1:]
 
 	err = cmds.run(programState, command{Name: cmdName, Args: cmdArgs})
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("command failed", "command", cmdName, "err", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// The aggregator's Prometheus metrics. They are always collected and only
+// exposed when metrics_addr is set in the config.
+var (
+	feedFetches = newCounterVec("gator_feed_fetches_total",
+		"Feed fetches by result.", "result")
+	feedFetchFailures = newCounterVec("gator_feed_fetch_failures_total",
+		"Failed feed fetches by reason.", "reason")
+	feedFetchRetries = newCounterVec("gator_feed_fetch_retries_total",
+		"Feed fetch attempts retried after a transient error.")
+	feedHTTPResponses = newCounterVec("gator_feed_http_responses_total",
+		"HTTP responses from feed servers by status code.", "code")
+	feedFetchDuration = newHistogramVec("gator_feed_fetch_duration_seconds",
+		"Time taken to fetch and parse a feed, including retries.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60})
+	postsStored = newCounterVec("gator_posts_total",
+		"Feed items processed by outcome (inserted, updated, duplicate).", "outcome")
+	ruleActions = newCounterVec("gator_rule_actions_total",
+		"Actions taken by user rules on new posts.", "action")
+	feedsDue = newGaugeVec("gator_feeds_due",
+		"Active feeds not fetched within the last hour.")
+	lastSuccessfulFetch = newGaugeVec("gator_last_successful_fetch_timestamp_seconds",
+		"Unix time of the last successful feed fetch.")
+	dbQueryDuration = newHistogramVec("gator_db_query_duration_seconds",
+		"Database query latency by sqlc query name.",
+		[]float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}, "query")
+
+	allMetrics = []metricWriter{
+		feedFetches, feedFetchFailures, feedFetchRetries, feedHTTPResponses,
+		feedFetchDuration, postsStored, ruleActions, feedsDue, lastSuccessfulFetch, dbQueryDuration,
+	}
+)
+
+// feedDueAfter is how long an active feed may go unfetched before it counts
+// towards the gator_feeds_due queue depth.
+const feedDueAfter = time.Hour
+
+type metricWriter interface {
+	writeTo(w io.Writer)
+}
+
+// labelKey joins label values into a map key.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func formatLabels(names []string, key string, extra ...string) string {
+	var pairs []string
+	if len(names) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", names[i], value))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type counterVec struct {
+	name, help string
+	labels     []string
+	kind       string
+	mu         sync.Mutex
+	values     map[string]float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return newMetric(name, help, "counter", labels)
+}
+
+func newGaugeVec(name, help string, labels ...string) *counterVec {
+	return newMetric(name, help, "gauge", labels)
+}
+
+func newMetric(name, help, kind string, labels []string) *counterVec {
+	c := &counterVec{name: name, help: help, labels: labels, kind: kind, values: map[string]float64{}}
+	if len(labels) == 0 {
+		// Unlabelled series are exported as 0 from the start so alerts on
+		// them don't have to special-case a missing series.
+		c.values[""] = 0
+	}
+	return c
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelKey(labelValues)] += v
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	c.add(1, labelValues...)
+}
+
+func (c *counterVec) set(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelKey(labelValues)] = v
+}
+
+func (c *counterVec) writeTo(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", c.name, c.help, c.name, c.kind)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, key), formatFloat(c.values[key]))
+	}
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+	mu         sync.Mutex
+	values     map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: map[string]*histogram{}}
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := labelKey(labelValues)
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.sum += v
+	hist.count++
+}
+
+func (h *histogramVec) writeTo(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.values) {
+		hist := h.values[key]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", formatFloat(bound)), hist.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, "le", "+Inf"), hist.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, key), formatFloat(hist.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, key), hist.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func metricsHandler(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, m := range allMetrics {
+		m.writeTo(rw)
+	}
+}
+
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", metricsHandler)
+	slog.Info("serving metrics", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		slog.Error("metrics server stopped", "err", err)
+	}
+}
+
+// fetchFailureReason buckets a fetch error for the failures counter.
+func fetchFailureReason(err error) string {
+	var statusErr *httpStatusError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &statusErr):
+		return "http_status"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &opErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return "connection"
+	case strings.HasPrefix(err.Error(), "couldn't parse feed"):
+		return "parse"
+	}
+	return "other"
+}
+
+// updateFeedsDue refreshes the due-feeds gauge.
+func updateFeedsDue(db *database.Queries) {
+	count, err := db.CountFeedsDueForFetch(context.Background(), feedDueAfter.Seconds())
+	if err != nil {
+		slog.Error("couldn't count due feeds", "err", err)
+		return
+	}
+	feedsDue.set(float64(count))
+}
+
+// instrumentedDB times every query sqlc issues, labelled with the query name
+// from the "-- name: X" header sqlc puts at the top of each statement.
+type instrumentedDB struct {
+	db database.DBTX
+}
+
+func (i instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return i.db.ExecContext(ctx, query, args...)
+}
+
+func (i instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return i.db.QueryContext(ctx, query, args...)
+}
+
+func (i instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return i.db.QueryRowContext(ctx, query, args...)
+}
+
+func observeQuery(query string, start time.Time) {
+	dbQueryDuration.observe(time.Since(start).Seconds(), queryName(query))
+}
+
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/sajidcodess/gator/internal/database"
 )
@@ -12,6 +13,7 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 		if err != nil {
 			return nil
 		}
+		slog.Debug("running command", "command", cmd.Name, "user", user.Name)
 		return handler(s, cmd, user)
 	}
 }
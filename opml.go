@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// opmlDocument covers the parts of OPML 1.0 and 2.0 that subscription lists
+// use: a title and a tree of outlines, where outlines with an xmlUrl are
+// feeds and outlines without one are folders.
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+func (o opmlOutline) name() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return o.Text
+}
+
+// opmlEntry is a feed outline flattened out of the document, along with the
+// folder it was found in.
+type opmlEntry struct {
+	name   string
+	url    string
+	folder string
+}
+
+// opmlEntries walks outlines depth first and returns every feed in them.
+// Feeds go in a folder named after the path of folder outlines they're
+// nested in, e.g. "News/Tech", or failing that after their first category.
+func opmlEntries(outlines []opmlOutline, folder string) []opmlEntry {
+	var entries []opmlEntry
+	for _, o := range outlines {
+		if o.XMLURL == "" {
+			entries = append(entries, opmlEntries(o.Outlines, opmlFolderPath(folder, o.name()))...)
+			continue
+		}
+		entry := opmlEntry{
+			name:   strings.TrimSpace(o.name()),
+			url:    strings.TrimSpace(o.XMLURL),
+			folder: folder,
+		}
+		if entry.folder == "" && o.Category != "" {
+			category, _, _ := strings.Cut(o.Category, ",")
+			for _, part := range strings.Split(category, "/") {
+				entry.folder = opmlFolderPath(entry.folder, part)
+			}
+		}
+		if entry.name == "" {
+			entry.name = entry.url
+		}
+		entries = append(entries, entry)
+		entries = append(entries, opmlEntries(o.Outlines, folder)...)
+	}
+	return entries
+}
+
+// opmlFolderPath appends a nested folder's name to the path of its parent,
+// skipping names that are blank.
+func opmlFolderPath(parent, name string) string {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return parent
+	case parent == "":
+		return name
+	}
+	return parent + "/" + name
+}
+
+func validFeedURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func importHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without changing anything")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage %s [--dry-run] <file.opml>", cmd.Name)
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("couldn't read %s: %w", fs.Arg(0), err)
+	}
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("couldn't parse %s as OPML: %w", fs.Arg(0), err)
+	}
+	entries := opmlEntries(doc.Body.Outlines, "")
+	if len(entries) == 0 {
+		return fmt.Errorf("no feeds found in %s", fs.Arg(0))
+	}
+
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op, and a dry run never commits.
+	defer tx.Rollback()
+	q := database.New(instrumentedDB{db: tx})
+
+	var created, followed, moved, alreadyFollowing, invalid []string
+	folders := make(map[string]uuid.UUID)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !validFeedURL(entry.url) {
+			invalid = append(invalid, fmt.Sprintf("%s (%q is not an http(s) URL)", entry.name, entry.url))
+			continue
+		}
+		if seen[entry.url] {
+			invalid = append(invalid, fmt.Sprintf("%s (%s is listed more than once)", entry.name, entry.url))
+			continue
+		}
+		seen[entry.url] = true
+
+		var folderID uuid.NullUUID
+		if entry.folder != "" {
+			id, ok := folders[entry.folder]
+			if !ok {
+				folder, err := q.GetOrCreateFolder(ctx, database.GetOrCreateFolderParams{
+					ID:        uuid.New(),
+					CreatedAt: time.Now().UTC(),
+					UpdatedAt: time.Now().UTC(),
+					UserID:    user.ID,
+					Name:      entry.folder,
+				})
+				if err != nil {
+					return fmt.Errorf("couldn't create folder %q: %w", entry.folder, err)
+				}
+				id = folder.ID
+				folders[entry.folder] = id
+			}
+			folderID = uuid.NullUUID{UUID: id, Valid: true}
+		}
+
+		feed, err := q.GetFeedByURL(ctx, entry.url)
+		isNew := errors.Is(err, sql.ErrNoRows)
+		if isNew {
+			feed, err = q.CreateFeed(ctx, database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now().UTC(),
+				UpdatedAt: time.Now().UTC(),
+				Name:      entry.name,
+				Url:       entry.url,
+				UserID:    user.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't import %s: %w", entry.url, err)
+		}
+
+		inserted, err := q.UpsertFeedFollow(ctx, database.UpsertFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			FolderID:  folderID,
+		})
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			// Already followed, and already in the OPML's folder if it has one.
+			alreadyFollowing = append(alreadyFollowing, feed.Name)
+		case err != nil:
+			return fmt.Errorf("couldn't follow %s: %w", entry.url, err)
+		case isNew:
+			created = append(created, feed.Name)
+		case inserted:
+			followed = append(followed, feed.Name)
+		default:
+			moved = append(moved, fmt.Sprintf("%s (to %s)", feed.Name, entry.folder))
+		}
+	}
+
+	if !*dryRun {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("couldn't commit import: %w", err)
+		}
+	}
+
+	printImportSection("Created and followed", created)
+	printImportSection("Already present, now followed", followed)
+	printImportSection("Already followed, moved to the OPML's folder", moved)
+	printImportSection("Already present and followed", alreadyFollowing)
+	printImportSection("Invalid", invalid)
+	fmt.Println("=====================================")
+	if *dryRun {
+		fmt.Println("Dry run: nothing was changed")
+	}
+	return nil
+}
+
+func printImportSection(title string, names []string) {
+	fmt.Printf("%s: %d\n", title, len(names))
+	for _, name := range names {
+		fmt.Printf("* %s\n", name)
+	}
+}
+
+func exportHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	asOPML := fs.Bool("opml", false, "export subscriptions as OPML 2.0")
+	search := fs.String("search", "", "export the latest posts of this saved search as RSS 2.0")
+	output := fs.String("output", "", "file to write to instead of stdout")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if *asOPML == (*search != "") || fs.NArg() != 0 {
+		return fmt.Errorf("usage %s --opml | --search name [--output file]", cmd.Name)
+	}
+
+	var data []byte
+	var n int
+	var err error
+	exported := "feeds"
+	if *asOPML {
+		data, n, err = exportOPML(s, user)
+	} else {
+		data, n, err = exportSavedSearchRSS(s, user, *search)
+		exported = "posts of " + *search
+	}
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return fmt.Errorf("couldn't write %s: %w", *output, err)
+	}
+	fmt.Printf("Exported %d %s to %s\n", n, exported, *output)
+	return nil
+}
+
+// exportOPML renders the user's follows as OPML and returns how many feeds
+// it holds.
+func exportOPML(s *state, user database.User) ([]byte, int, error) {
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       fmt.Sprintf("%s's subscriptions in gator", user.Name),
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	// Follows come sorted by folder, so each folder's feeds are contiguous.
+	folder := -1
+	for _, ff := range follows {
+		name := ff.FeedName
+		if ff.DisplayName.Valid {
+			name = ff.DisplayName.String
+		}
+		outline := opmlOutline{
+			Text:    name,
+			Title:   name,
+			Type:    "rss",
+			XMLURL:  ff.FeedUrl,
+			HTMLURL: ff.SiteUrl.String,
+		}
+		if !ff.FolderName.Valid {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+		if folder < 0 || doc.Body.Outlines[folder].Text != ff.FolderName.String {
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{Text: ff.FolderName.String, Title: ff.FolderName.String})
+			folder = len(doc.Body.Outlines) - 1
+		}
+		doc.Body.Outlines[folder].Outlines = append(doc.Body.Outlines[folder].Outlines, outline)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't encode OPML: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), len(follows), nil
+}
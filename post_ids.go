@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// shortIDLength is the fewest characters of a UUID shown in listings. It's a
+// prefix, so it never changes for a given ID.
+const shortIDLength = 8
+
+// shortID shortens the ID of a rule or filter. A user has few enough of
+// those that the shortest length is enough to tell them apart.
+func shortID(id uuid.UUID) string {
+	return id.String()[:shortIDLength]
+}
+
+// postShortIDs works out the short ID to show for each post: the shortest
+// prefix, at least shortIDLength long, that no other post in the user's
+// followed feeds starts with.
+func postShortIDs(s *state, user database.User, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	var params database.GetPostIDsInRangesParams
+	params.UserID = user.ID
+	for _, id := range ids {
+		low, high := prefixRange(shortID(id))
+		params.Lows = append(params.Lows, low)
+		params.Highs = append(params.Highs, high)
+	}
+	neighbours, err := s.db.GetPostIDsInRanges(context.Background(), params)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get post ids: %w", err)
+	}
+	return uniquePrefixes(ids, neighbours), nil
+}
+
+// uniquePrefixes returns, for each of ids, its shortest prefix of at least
+// shortIDLength characters that none of the other IDs in neighbours share.
+// A prefix never ends in a dash.
+func uniquePrefixes(ids, neighbours []uuid.UUID) map[uuid.UUID]string {
+	short := make(map[uuid.UUID]string, len(ids))
+	for _, id := range ids {
+		full := id.String()
+		n := shortIDLength
+		for _, other := range neighbours {
+			if other != id {
+				n = max(n, commonPrefixLength(full, other.String())+1)
+			}
+		}
+		if n < len(full) && full[n-1] == '-' {
+			n++
+		}
+		short[id] = full[:min(n, len(full))]
+	}
+	return short
+}
+
+// postShortID is postShortIDs for a single post.
+func postShortID(s *state, user database.User, id uuid.UUID) (string, error) {
+	short, err := postShortIDs(s, user, []uuid.UUID{id})
+	if err != nil {
+		return "", err
+	}
+	return short[id], nil
+}
+
+func commonPrefixLength(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// prefixRange returns the lowest and highest UUIDs that start with prefix.
+// prefix must pass isUUIDPrefix.
+func prefixRange(prefix string) (uuid.UUID, uuid.UUID) {
+	hex := strings.ReplaceAll(prefix, "-", "")
+	low := uuid.MustParse(hex + strings.Repeat("0", 32-len(hex)))
+	high := uuid.MustParse(hex + strings.Repeat("f", 32-len(hex)))
+	return low, high
+}
+
+// isUUIDPrefix reports whether prefix could be the start of a UUID written
+// out in lowercase with dashes.
+func isUUIDPrefix(prefix string) bool {
+	const layout = "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+	if len(prefix) > len(layout) {
+		return false
+	}
+	for i, c := range []byte(prefix) {
+		if layout[i] == '-' {
+			if c != '-' {
+				return false
+			}
+		} else if !strings.ContainsRune("0123456789abcdef", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvePostID turns a full post UUID, or a prefix of one that is unique
+// among the posts in the user's followed feeds, into the post's ID.
+func resolvePostID(s *state, user database.User, arg string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(arg); err == nil {
+		return id, nil
+	}
+	prefix := strings.ToLower(arg)
+	if len(prefix) < 4 || !isUUIDPrefix(prefix) {
+		return uuid.Nil, fmt.Errorf("invalid post id %q", arg)
+	}
+	low, high := prefixRange(prefix)
+	ids, err := s.db.GetPostIDsByPrefix(context.Background(), database.GetPostIDsByPrefixParams{
+		UserID: user.ID,
+		Low:    low,
+		High:   high,
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("couldn't look up post %q: %w", arg, err)
+	}
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("no post with id %q", arg)
+	case 1:
+		return ids[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("post id %q is ambiguous, use more characters", arg)
+	}
+}
+
+// matchIDPrefix finds the one ID in ids that starts with arg, so commands
+// can take the short IDs shown in their listings. what names the kind of
+// thing in error messages.
+func matchIDPrefix(ids []uuid.UUID, arg, what string) (uuid.UUID, error) {
+	var matches []uuid.UUID
+	for _, id := range ids {
+		if strings.HasPrefix(id.String(), strings.ToLower(arg)) {
+			matches = append(matches, id)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("no %s with id %q", what, arg)
+	case 1:
+		return matches[0], nil
+	default:
+		return uuid.Nil, fmt.Errorf("%s id %q is ambiguous, use more characters", what, arg)
+	}
+}
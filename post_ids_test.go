@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUniquePrefixes(t *testing.T) {
+	id := uuid.MustParse("0123abcd-ef01-4567-89ab-cdef01234567")
+	tests := []struct {
+		name       string
+		neighbours []string
+		want       string
+	}{
+		{"alone", nil, "0123abcd"},
+		{"only itself", []string{"0123abcd-ef01-4567-89ab-cdef01234567"}, "0123abcd"},
+		{"shares fewer than 8", []string{"0123a000-0000-4000-8000-000000000000"}, "0123abcd"},
+		{"shares 7", []string{"0123abc0-0000-4000-8000-000000000000"}, "0123abcd"},
+		{"shares 8, skipping the dash", []string{"0123abcd-0000-4000-8000-000000000000"}, "0123abcd-e"},
+		{"shares 10", []string{"0123abcd-e000-4000-8000-000000000000"}, "0123abcd-ef"},
+		{"longest shared prefix wins", []string{
+			"0123abcd-0000-4000-8000-000000000000",
+			"0123abcd-ef01-4000-8000-000000000000",
+			"0123abcd-e000-4000-8000-000000000000",
+		}, "0123abcd-ef01-45"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			neighbours := []uuid.UUID{id}
+			for _, n := range tt.neighbours {
+				neighbours = append(neighbours, uuid.MustParse(n))
+			}
+			if got := uniquePrefixes([]uuid.UUID{id}, neighbours)[id]; got != tt.want {
+				t.Errorf("short ID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniquePrefixesOfEachOther(t *testing.T) {
+	a := uuid.MustParse("0123abcd-ef01-4567-89ab-cdef01234567")
+	b := uuid.MustParse("0123abcd-ef99-4567-89ab-cdef01234567")
+	got := uniquePrefixes([]uuid.UUID{a, b}, []uuid.UUID{a, b})
+	if got[a] != "0123abcd-ef0" || got[b] != "0123abcd-ef9" {
+		t.Errorf("short IDs = %q and %q, want 0123abcd-ef0 and 0123abcd-ef9", got[a], got[b])
+	}
+}
+
+func TestPrefixRange(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		low, high string
+	}{
+		{"0123", "01230000-0000-0000-0000-000000000000", "0123ffff-ffff-ffff-ffff-ffffffffffff"},
+		{"0123a", "0123a000-0000-0000-0000-000000000000", "0123afff-ffff-ffff-ffff-ffffffffffff"},
+		{"0123abcd", "0123abcd-0000-0000-0000-000000000000", "0123abcd-ffff-ffff-ffff-ffffffffffff"},
+		{"0123abcd-", "0123abcd-0000-0000-0000-000000000000", "0123abcd-ffff-ffff-ffff-ffffffffffff"},
+		{"0123abcd-e", "0123abcd-e000-0000-0000-000000000000", "0123abcd-efff-ffff-ffff-ffffffffffff"},
+		{"0123abcd-ef0", "0123abcd-ef00-0000-0000-000000000000", "0123abcd-ef0f-ffff-ffff-ffffffffffff"},
+	}
+	for _, tt := range tests {
+		low, high := prefixRange(tt.prefix)
+		if low.String() != tt.low || high.String() != tt.high {
+			t.Errorf("prefixRange(%q) = %s, %s, want %s, %s", tt.prefix, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestPrefixRangeContainsID(t *testing.T) {
+	for range 20 {
+		id := uuid.New()
+		full := id.String()
+		for n := 4; n <= len(full); n++ {
+			low, high := prefixRange(full[:n])
+			if bytes.Compare(low[:], id[:]) > 0 || bytes.Compare(id[:], high[:]) > 0 {
+				t.Fatalf("%s is outside prefixRange(%q) = %s, %s", id, full[:n], low, high)
+			}
+		}
+	}
+}
+
+func TestIsUUIDPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   bool
+	}{
+		{"0123", true},
+		{"0123a", true},
+		{"0123abcd-", true},
+		{"0123abcd-e", true},
+		{"0123abcd-ef01-4567-89ab-cdef01234567", true},
+		{"0123abcde", false},
+		{"0123ABCD", false},
+		{"0123-", false},
+		{"xyz", false},
+		{"0123abcd-ef01-4567-89ab-cdef012345670", false},
+	}
+	for _, tt := range tests {
+		if got := isUUIDPrefix(tt.prefix); got != tt.want {
+			t.Errorf("isUUIDPrefix(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
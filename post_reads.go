@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+func markPostRead(s *state, user database.User, postID uuid.UUID) error {
+	err := s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+		ReadAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't mark post %s read: %w", postID, err)
+	}
+	return nil
+}
+
+func unreadHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf("usage %s <post_id>...", cmd.Name)
+	}
+	for _, arg := range cmd.Args {
+		postID, err := resolvePostID(s, user, arg)
+		if err != nil {
+			return err
+		}
+		if _, err := s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: postID,
+		}); err != nil {
+			return fmt.Errorf("couldn't mark post %s unread: %w", postID, err)
+		}
+	}
+	fmt.Printf("Marked %d post(s) as unread\n", len(cmd.Args))
+	return nil
+}
+
+func markAllReadHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage %s [feed_URL]", cmd.Name)
+	}
+	params := database.MarkAllPostsReadParams{
+		ReadAt: time.Now().UTC(),
+		UserID: user.ID,
+	}
+	if len(cmd.Args) == 1 {
+		feed, err := s.db.GetFeedByURL(context.Background(), cmd.Args[0])
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("no feed with URL %s", cmd.Args[0])
+			}
+			return err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	n, err := s.db.MarkAllPostsRead(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't mark posts read: %w", err)
+	}
+	fmt.Printf("Marked %d post(s) as read\n", n)
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// purgeBatchSize is how many posts purge reads, archives and deletes at a
+// time.
+const purgeBatchSize = 500
+
+type purgeOptions struct {
+	dryRun     bool
+	archiveDir string
+}
+
+type purgeResult struct {
+	// byFeed counts the posts past retention by feed URL.
+	byFeed  map[string]int
+	matched int
+	deleted int64
+	archive string
+}
+
+// purgePosts deletes the posts that are past their feed's retention, or
+// only counts them on a dry run. With an archive directory, each batch is
+// written to the archive before it is deleted, so a failure part way never
+// loses posts.
+func purgePosts(ctx context.Context, s *state, opts purgeOptions) (result purgeResult, err error) {
+	result.byFeed = make(map[string]int)
+	now := time.Now().UTC()
+
+	var archive *postArchive
+	if opts.archiveDir != "" && !opts.dryRun {
+		archive, err = createPostArchive(opts.archiveDir, now)
+		if err != nil {
+			return result, err
+		}
+		defer func() {
+			if closeErr := archive.close(result.matched == 0); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	// The posts to purge are worked out once, as ranking every feed's posts
+	// again for each batch would take time quadratic in the number of posts.
+	ids, err := s.db.GetPostIDsToPurge(ctx, database.GetPostIDsToPurgeParams{
+		DefaultPosts: int32(s.cfg.PostRetentionPosts),
+		DefaultDays:  int32(s.cfg.PostRetentionDays),
+		Now:          now,
+	})
+	if err != nil {
+		return result, fmt.Errorf("couldn't find posts to purge: %w", err)
+	}
+	for batch := range slices.Chunk(ids, purgeBatchSize) {
+		posts, err := s.db.GetPostsToPurge(ctx, batch)
+		if err != nil {
+			return result, fmt.Errorf("couldn't get posts to purge: %w", err)
+		}
+		for _, post := range posts {
+			result.byFeed[post.FeedUrl]++
+		}
+		result.matched += len(posts)
+
+		if archive != nil {
+			if err := archive.write(posts); err != nil {
+				return result, err
+			}
+			result.archive = archive.path
+		}
+		if !opts.dryRun {
+			n, err := s.db.DeletePosts(ctx, batch)
+			if err != nil {
+				return result, fmt.Errorf("couldn't delete posts: %w", err)
+			}
+			result.deleted += n
+		}
+	}
+	return result, nil
+}
+
+func purgeHandler(s *state, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be purged without deleting anything")
+	archiveDir := fs.String("archive", s.cfg.ArchiveDir, "directory to archive purged posts to as gzipped JSON lines")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage %s [--dry-run] [--archive dir]", cmd.Name)
+	}
+
+	result, err := purgePosts(context.Background(), s, purgeOptions{
+		dryRun:     *dryRun,
+		archiveDir: *archiveDir,
+	})
+	if err != nil {
+		return err
+	}
+	if result.matched == 0 {
+		fmt.Println("No posts are past their retention.")
+		return nil
+	}
+
+	feeds := make([]string, 0, len(result.byFeed))
+	for feed := range result.byFeed {
+		feeds = append(feeds, feed)
+	}
+	slices.Sort(feeds)
+	for _, feed := range feeds {
+		fmt.Printf("* %s: %d posts\n", feed, result.byFeed[feed])
+	}
+	fmt.Println("=====================================")
+	if *dryRun {
+		fmt.Printf("Dry run: %d posts would be deleted\n", result.matched)
+		return nil
+	}
+	fmt.Printf("Deleted %d posts\n", result.deleted)
+	if result.archive != "" {
+		fmt.Printf("Archived to %s\n", result.archive)
+	}
+	return nil
+}
+
+// archivedPost is one line of a purge archive.
+type archivedPost struct {
+	ID          uuid.UUID  `json:"id"`
+	FeedID      uuid.UUID  `json:"feed_id"`
+	FeedURL     string     `json:"feed_url"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Categories  []string   `json:"categories,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// postArchive is a gzipped JSON lines file that purged posts are written to.
+type postArchive struct {
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func createPostArchive(dir string, now time.Time) (*postArchive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("couldn't create archive directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("posts-%s.jsonl.gz", now.Format("20060102T150405Z")))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create archive: %w", err)
+	}
+	gz := gzip.NewWriter(file)
+	return &postArchive{path: path, file: file, gz: gz, enc: json.NewEncoder(gz)}, nil
+}
+
+// write appends posts to the archive and flushes them to disk, so they're
+// safely stored before they're deleted.
+func (a *postArchive) write(posts []database.GetPostsToPurgeRow) error {
+	for _, post := range posts {
+		line := archivedPost{
+			ID:          post.ID,
+			FeedID:      post.FeedID,
+			FeedURL:     post.FeedUrl,
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String,
+			Content:     post.Content.String,
+			Author:      post.Author.String,
+			Categories:  post.Categories,
+			CreatedAt:   post.CreatedAt,
+			UpdatedAt:   post.UpdatedAt,
+		}
+		if post.PublishedAt.Valid {
+			line.PublishedAt = &post.PublishedAt.Time
+		}
+		if err := a.enc.Encode(line); err != nil {
+			return fmt.Errorf("couldn't write archive: %w", err)
+		}
+	}
+	if err := a.gz.Flush(); err != nil {
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("couldn't write archive: %w", err)
+	}
+	return nil
+}
+
+// close finishes the archive, removing it when nothing was written to it.
+func (a *postArchive) close(empty bool) error {
+	err := errors.Join(a.gz.Close(), a.file.Close())
+	if err != nil {
+		return fmt.Errorf("couldn't finish archive: %w", err)
+	}
+	if empty {
+		return os.Remove(a.path)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// readerMaxWidth keeps lines readable on very wide terminals.
+const readerMaxWidth = 100
+
+func getPost(s *state, postID uuid.UUID) (database.GetPostByIDRow, error) {
+	post, err := s.db.GetPostByID(context.Background(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return post, fmt.Errorf("no post with id %s", postID)
+		}
+		return post, fmt.Errorf("couldn't get post %s: %w", postID, err)
+	}
+	return post, nil
+}
+
+func openHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage %s <post_id>", cmd.Name)
+	}
+	postID, err := resolvePostID(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	post, err := getPost(s, postID)
+	if err != nil {
+		return err
+	}
+	if err := openURL(post.Url); err != nil {
+		return err
+	}
+	fmt.Printf("Opened %s\n", post.Url)
+	return nil
+}
+
+func readHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf("usage %s <post_id>...", cmd.Name)
+	}
+	width := readerMaxWidth
+	if cols, _, err := terminalSize(); err == nil && cols < width {
+		width = cols
+	}
+
+	var b strings.Builder
+	var postIDs []uuid.UUID
+	for _, arg := range cmd.Args {
+		postID, err := resolvePostID(s, user, arg)
+		if err != nil {
+			return err
+		}
+		post, err := getPost(s, postID)
+		if err != nil {
+			return err
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n" + strings.Repeat("=", width) + "\n\n")
+		}
+		writePost(&b, post, width)
+		postIDs = append(postIDs, postID)
+	}
+
+	if err := page(b.String()); err != nil {
+		return err
+	}
+	for _, postID := range postIDs {
+		if err := markPostRead(s, user, postID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePost renders a post as plain text wrapped to width columns.
+func writePost(w io.Writer, post database.GetPostByIDRow, width int) {
+	fmt.Fprintf(w, "%s\n", post.Title)
+	fmt.Fprintf(w, "%s", post.FeedName)
+	if post.PublishedAt.Valid {
+		fmt.Fprintf(w, " - %s", post.PublishedAt.Time.Format("Mon Jan 2 2006 15:04"))
+	}
+	fmt.Fprintln(w)
+	if post.Author.Valid {
+		fmt.Fprintf(w, "by %s\n", post.Author.String)
+	}
+	fmt.Fprintf(w, "%s\n\n", post.Url)
+
+	body := post.Content.String
+	if body == "" {
+		body = post.Description.String
+	}
+	for _, line := range wrapParagraphs(plainParagraphs(body), width) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// page shows text through $PAGER, or less when it isn't set. Without a
+// terminal, or when the pager can't be started, the text is printed as is.
+func page(text string) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+	if !isTerminal(os.Stdout) {
+		_, err := io.WriteString(os.Stdout, text)
+		return err
+	}
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return fmt.Errorf("pager %s failed: %w", pager[0], err)
+		}
+		_, err := io.WriteString(os.Stdout, text)
+		return err
+	}
+	return nil
+}
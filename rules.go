@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const ruleUsage = "usage %s add [conditions] [--tag name] [--star] [--read] [--notify] | list | remove <rule_id> | test [--limit n] <rule_id | conditions>\n" +
+	"conditions: [--feed feed_URL] [--title regex] [--content regex]"
+
+func ruleHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf(ruleUsage, cmd.Name)
+	}
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "add":
+		return addRule(s, user, command{Name: cmd.Name + " add", Args: args})
+	case "list":
+		if len(args) != 0 {
+			return fmt.Errorf(ruleUsage, cmd.Name)
+		}
+		return listRules(s, user)
+	case "remove":
+		if len(args) != 1 {
+			return fmt.Errorf(ruleUsage, cmd.Name)
+		}
+		return removeRule(s, user, args[0])
+	case "test":
+		return testRule(s, user, command{Name: cmd.Name + " test", Args: args})
+	default:
+		return fmt.Errorf(ruleUsage, cmd.Name)
+	}
+}
+
+// ruleConditions are the parts of a rule that decide which posts it applies
+// to. Empty conditions match everything.
+type ruleConditions struct {
+	feedURL string
+	title   string
+	content string
+}
+
+func addRuleConditionFlags(fs *flag.FlagSet) *ruleConditions {
+	var c ruleConditions
+	fs.StringVar(&c.feedURL, "feed", "", "only match posts from this feed")
+	fs.StringVar(&c.title, "title", "", "regular expression the title must match")
+	fs.StringVar(&c.content, "content", "", "regular expression the description or content must match")
+	return &c
+}
+
+func (c ruleConditions) empty() bool {
+	return c.feedURL == "" && c.title == "" && c.content == ""
+}
+
+// resolve checks the patterns and looks up the feed, returning the
+// conditions in the form rule_matches takes them.
+func (c ruleConditions) resolve(s *state) (feedID uuid.NullUUID, title, content sql.NullString, err error) {
+	for _, pattern := range []string{c.title, c.content} {
+		if pattern == "" {
+			continue
+		}
+		if err := s.db.ValidateRegex(context.Background(), pattern); err != nil {
+			return feedID, title, content, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+	}
+	if c.feedURL != "" {
+		feed, err := lookupFeed(s, c.feedURL)
+		if err != nil {
+			return feedID, title, content, err
+		}
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	title = sql.NullString{String: c.title, Valid: c.title != ""}
+	content = sql.NullString{String: c.content, Valid: c.content != ""}
+	return feedID, title, content, nil
+}
+
+func addRule(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	conditions := addRuleConditionFlags(fs)
+	tagName := fs.String("tag", "", "tag matching posts")
+	star := fs.Bool("star", false, "save matching posts")
+	markRead := fs.Bool("read", false, "mark matching posts read")
+	notify := fs.Bool("notify", false, "add matching posts to your notifications")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("usage %s [--feed feed_URL] [--title regex] [--content regex] [--tag name] [--star] [--read] [--notify]", cmd.Name)
+	}
+	*tagName = strings.TrimSpace(*tagName)
+	if *tagName == "" && !*star && !*markRead && !*notify {
+		return errors.New("a rule needs at least one action: --tag, --star, --read or --notify")
+	}
+	feedID, title, content, err := conditions.resolve(s)
+	if err != nil {
+		return err
+	}
+
+	var tagID uuid.NullUUID
+	if *tagName != "" {
+		tag, err := s.db.GetOrCreateTag(context.Background(), database.GetOrCreateTagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			Name:      *tagName,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't create tag %q: %w", *tagName, err)
+		}
+		tagID = uuid.NullUUID{UUID: tag.ID, Valid: true}
+	}
+
+	rule, err := s.db.CreateRule(context.Background(), database.CreateRuleParams{
+		ID:             uuid.New(),
+		CreatedAt:      time.Now().UTC(),
+		UserID:         user.ID,
+		FeedID:         feedID,
+		TitlePattern:   title,
+		ContentPattern: content,
+		TagID:          tagID,
+		Star:           *star,
+		MarkRead:       *markRead,
+		Notify:         *notify,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't create rule: %w", err)
+	}
+	fmt.Printf("Rule %s added; it applies to posts fetched from now on\n", shortID(rule.ID))
+	if conditions.empty() {
+		fmt.Println("It has no conditions, so it matches every post of every feed you follow.")
+	}
+	return nil
+}
+
+func listRules(s *state, user database.User) error {
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get rules: %w", err)
+	}
+	if len(rules) == 0 {
+		fmt.Println("No rules found for this user.")
+		return nil
+	}
+	fmt.Printf("Rules for user %s:\n", user.Name)
+	for _, r := range rules {
+		var when []string
+		if r.TitlePattern.Valid {
+			when = append(when, fmt.Sprintf("title ~ /%s/", r.TitlePattern.String))
+		}
+		if r.ContentPattern.Valid {
+			when = append(when, fmt.Sprintf("content ~ /%s/", r.ContentPattern.String))
+		}
+		if len(when) == 0 {
+			when = append(when, "any post")
+		}
+		scope := "all feeds"
+		if r.FeedName.Valid {
+			scope = r.FeedName.String
+		}
+
+		var actions []string
+		if r.TagName.Valid {
+			actions = append(actions, "tag "+r.TagName.String)
+		}
+		if r.Star {
+			actions = append(actions, "star")
+		}
+		if r.MarkRead {
+			actions = append(actions, "mark read")
+		}
+		if r.Notify {
+			actions = append(actions, "notify")
+		}
+		fmt.Printf("* %s  %s in %s -> %s\n", shortID(r.ID), strings.Join(when, " and "), scope, strings.Join(actions, ", "))
+	}
+	return nil
+}
+
+func removeRule(s *state, user database.User, arg string) error {
+	rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get rules: %w", err)
+	}
+	ids := make([]uuid.UUID, len(rules))
+	for i, r := range rules {
+		ids[i] = r.ID
+	}
+	id, err := matchIDPrefix(ids, arg, "rule")
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.DeleteRule(context.Background(), database.DeleteRuleParams{
+		ID:     id,
+		UserID: user.ID,
+	}); err != nil {
+		return fmt.Errorf("couldn't remove rule: %w", err)
+	}
+	fmt.Printf("Rule %s has been removed\n", shortID(id))
+	return nil
+}
+
+// testRule backtests a saved rule, or a rule described by condition flags,
+// against the posts already stored for the feeds the user follows. It
+// doesn't apply any actions.
+func testRule(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	conditions := addRuleConditionFlags(fs)
+	limit := fs.Int("limit", 20, "number of matching posts to show")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 || (fs.NArg() == 1 && !conditions.empty()) || *limit < 1 {
+		return fmt.Errorf("usage %s [--limit n] <rule_id | [--feed feed_URL] [--title regex] [--content regex]>", cmd.Name)
+	}
+
+	params := database.BacktestRuleParams{
+		UserID: user.ID,
+		Limit:  int32(*limit),
+	}
+	if fs.NArg() == 1 {
+		rules, err := s.db.GetRulesForUser(context.Background(), user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't get rules: %w", err)
+		}
+		ids := make([]uuid.UUID, len(rules))
+		for i, r := range rules {
+			ids[i] = r.ID
+		}
+		id, err := matchIDPrefix(ids, fs.Arg(0), "rule")
+		if err != nil {
+			return err
+		}
+		for _, r := range rules {
+			if r.ID == id {
+				params.FeedID = r.FeedID
+				params.TitlePattern = r.TitlePattern
+				params.ContentPattern = r.ContentPattern
+			}
+		}
+	} else {
+		var err error
+		params.FeedID, params.TitlePattern, params.ContentPattern, err = conditions.resolve(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	posts, err := s.db.BacktestRule(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't backtest rule: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Println("No existing posts match this rule.")
+		return nil
+	}
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	shortIDs, err := postShortIDs(s, user, ids)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d existing posts match this rule", posts[0].Total)
+	if posts[0].Total > int64(len(posts)) {
+		fmt.Printf(", showing the latest %d", len(posts))
+	}
+	fmt.Println(":")
+	for _, post := range posts {
+		fmt.Printf("* %s  [%s] %s\n", shortIDs[post.ID], post.FeedName, post.Title)
+	}
+	return nil
+}
+
+// applyRules runs the actions of every rule that matches a newly inserted
+// post, for each user following its feed. Failures are logged rather than
+// returned so one bad rule doesn't stop the feed from being stored.
+func applyRules(db *database.Queries, feed database.Feed, postID uuid.UUID) {
+	logger := feedLogger(feed)
+	rules, err := db.GetRulesMatchingPost(context.Background(), postID)
+	if err != nil {
+		logger.Error("couldn't evaluate rules", "post_id", postID, "err", err)
+		return
+	}
+	for _, rule := range rules {
+		now := time.Now().UTC()
+		if rule.TagID.Valid {
+			err := db.TagPost(context.Background(), database.TagPostParams{
+				PostID:    postID,
+				TagID:     rule.TagID.UUID,
+				CreatedAt: now,
+			})
+			logRuleAction(feed, rule, postID, "tag", err)
+		}
+		if rule.Star {
+			err := db.SavePostIfNotSaved(context.Background(), database.SavePostIfNotSavedParams{
+				UserID:    rule.UserID,
+				PostID:    postID,
+				CreatedAt: now,
+			})
+			logRuleAction(feed, rule, postID, "star", err)
+		}
+		if rule.MarkRead {
+			err := db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+				UserID: rule.UserID,
+				PostID: postID,
+				ReadAt: now,
+			})
+			logRuleAction(feed, rule, postID, "read", err)
+		}
+		if rule.Notify {
+			err := db.CreateNotification(context.Background(), database.CreateNotificationParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UserID:    rule.UserID,
+				PostID:    postID,
+				RuleID:    uuid.NullUUID{UUID: rule.ID, Valid: true},
+			})
+			logRuleAction(feed, rule, postID, "notify", err)
+		}
+	}
+}
+
+func logRuleAction(feed database.Feed, rule database.Rule, postID uuid.UUID, action string, err error) {
+	if err != nil {
+		feedLogger(feed).Error("couldn't apply rule", "rule_id", rule.ID, "post_id", postID, "action", action, "err", err)
+		return
+	}
+	ruleActions.inc(action)
+}
+
+func notificationsHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	all := fs.Bool("all", false, "include notifications you've already seen")
+	limit := fs.Int("limit", 50, "maximum number of notifications to show")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *limit < 1 {
+		return fmt.Errorf("usage %s [--all] [--limit n]", cmd.Name)
+	}
+
+	notifications, err := s.db.GetNotificationsForUser(context.Background(), database.GetNotificationsForUserParams{
+		UserID:      user.ID,
+		IncludeSeen: *all,
+		Limit:       int32(*limit),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get notifications: %w", err)
+	}
+	if len(notifications) == 0 {
+		fmt.Println("No new notifications.")
+		return nil
+	}
+	ids := make([]uuid.UUID, len(notifications))
+	for i, n := range notifications {
+		ids[i] = n.PostID
+	}
+	shortIDs, err := postShortIDs(s, user, ids)
+	if err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		isNew := ""
+		if *all && !n.SeenAt.Valid {
+			isNew = " (new)"
+		}
+		fmt.Printf("* %s  %s  [%s] %s%s\n", shortIDs[n.PostID], n.CreatedAt.Format(time.DateTime), n.FeedName, n.Title, isNew)
+		fmt.Printf("    %s\n", n.Url)
+	}
+
+	if _, err := s.db.MarkNotificationsSeen(context.Background(), database.MarkNotificationsSeenParams{
+		UserID: user.ID,
+		SeenAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("couldn't mark notifications seen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+func saveHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("usage %s <post_id> [note...]", cmd.Name)
+	}
+	postID, err := resolvePostID(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	note := strings.Join(cmd.Args[1:], " ")
+	_, err = s.db.SavePost(context.Background(), database.SavePostParams{
+		UserID:    user.ID,
+		PostID:    postID,
+		CreatedAt: time.Now().UTC(),
+		Note:      sql.NullString{String: note, Valid: note != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save post: %w", err)
+	}
+	fmt.Println("The post has been saved")
+	return nil
+}
+
+func unsaveHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage %s <post_id>", cmd.Name)
+	}
+	postID, err := resolvePostID(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	n, err := s.db.UnsavePost(context.Background(), database.UnsavePostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unsave post: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("post %s is not saved", postID)
+	}
+	fmt.Println("The post has been removed from your saved posts")
+	return nil
+}
+
+func savedHandler(s *state, cmd command, user database.User) error {
+	posts, err := s.db.GetSavedPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get saved posts: %w", err)
+	}
+	if len(posts) == 0 {
+		fmt.Println("No saved posts found for this user.")
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	shortIDs, err := postShortIDs(s, user, ids)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Found %d saved posts for user %s:\n", len(posts), user.Name)
+	for _, post := range posts {
+		fmt.Printf("%s from %s, saved %s\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName, post.SavedAt.Format("Mon Jan 2"))
+		fmt.Printf("--- %s ---\n", post.Title)
+		if post.Note.Valid {
+			fmt.Printf("Note: %s\n", post.Note.String)
+		}
+		fmt.Printf("Link: %s\n", post.Url)
+		fmt.Printf("ID:   %s\n", shortIDs[post.ID])
+		fmt.Println("=====================================")
+	}
+	return nil
+}
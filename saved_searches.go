@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// savedSearchExportLimit is how many of the latest posts an exported saved
+// search feed holds.
+const savedSearchExportLimit = 50
+
+const savedSearchUsage = "usage %s add [--keyword text] [--author text] [--feed name_or_url]... [--folder name] [--tag name] [--since time] [--until time] <name> | list | remove <name>"
+
+// stringList collects the values of a flag that may be given more than once.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func savedSearchHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) == 0 {
+		return fmt.Errorf(savedSearchUsage, cmd.Name)
+	}
+	args := cmd.Args[1:]
+	switch cmd.Args[0] {
+	case "add":
+		return addSavedSearch(s, user, command{Name: cmd.Name + " add", Args: args})
+	case "list":
+		if len(args) != 0 {
+			return fmt.Errorf(savedSearchUsage, cmd.Name)
+		}
+		return listSavedSearches(s, user)
+	case "remove":
+		if len(args) != 1 {
+			return fmt.Errorf(savedSearchUsage, cmd.Name)
+		}
+		return removeSavedSearch(s, user, args[0])
+	default:
+		return fmt.Errorf(savedSearchUsage, cmd.Name)
+	}
+}
+
+func addSavedSearch(s *state, user database.User, cmd command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	keyword := fs.String("keyword", "", "match posts whose title or description contains this text")
+	author := fs.String("author", "", "match posts whose author contains this text")
+	var feeds stringList
+	fs.Var(&feeds, "feed", "match posts from the feed with this name or URL; may be repeated")
+	folder := fs.String("folder", "", "match posts from feeds in this folder")
+	tag := fs.String("tag", "", "match posts with this tag")
+	since := fs.String("since", "", "match posts published within this window, e.g. 7d, or since a date")
+	until := fs.String("until", "", "match posts published before this time, e.g. 1d or a date")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage %s [--keyword text] [--author text] [--feed name_or_url]... [--folder name] [--tag name] [--since time] [--until time] <name>", cmd.Name)
+	}
+	if *keyword == "" && *author == "" && len(feeds) == 0 && *folder == "" && *tag == "" && *since == "" && *until == "" {
+		return errors.New("a saved search needs at least one of --keyword, --author, --feed, --folder, --tag, --since or --until")
+	}
+	for _, value := range []string{*since, *until} {
+		if value == "" {
+			continue
+		}
+		if _, err := parseBrowseTime(value, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	// Feeds are stored by ID, so renaming one doesn't break the search.
+	var feedIDs []uuid.UUID
+	for _, feed := range feeds {
+		ids, err := followedFeedIDs(s, user, feed)
+		if err != nil {
+			return err
+		}
+		feedIDs = append(feedIDs, ids...)
+	}
+
+	search, err := s.db.UpsertSavedSearch(context.Background(), database.UpsertSavedSearchParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		UserID:    user.ID,
+		Name:      fs.Arg(0),
+		Keyword:   sql.NullString{String: *keyword, Valid: *keyword != ""},
+		Author:    sql.NullString{String: *author, Valid: *author != ""},
+		FeedIds:   feedIDs,
+		Folder:    sql.NullString{String: *folder, Valid: *folder != ""},
+		Tag:       sql.NullString{String: *tag, Valid: *tag != ""},
+		Since:     sql.NullString{String: *since, Valid: *since != ""},
+		Until:     sql.NullString{String: *until, Valid: *until != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save search: %w", err)
+	}
+	description, err := describeSavedSearch(s, search)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved search %s: %s\n", search.Name, description)
+	fmt.Printf("Browse it with: browse --search %s\n", search.Name)
+	return nil
+}
+
+func listSavedSearches(s *state, user database.User) error {
+	searches, err := s.db.GetSavedSearchesForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get saved searches: %w", err)
+	}
+	if len(searches) == 0 {
+		fmt.Println("No saved searches found for this user.")
+		return nil
+	}
+	fmt.Printf("Saved searches for user %s:\n", user.Name)
+	for _, search := range searches {
+		unread, err := savedSearchUnread(s, user, search)
+		if err != nil {
+			return err
+		}
+		description, err := describeSavedSearch(s, search)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("* %s (%d unread): %s\n", search.Name, unread, description)
+	}
+	return nil
+}
+
+func removeSavedSearch(s *state, user database.User, name string) error {
+	n, err := s.db.DeleteSavedSearch(context.Background(), database.DeleteSavedSearchParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't remove saved search: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("no saved search named %q", name)
+	}
+	fmt.Printf("Saved search %s has been removed\n", name)
+	return nil
+}
+
+func lookupSavedSearch(s *state, user database.User, name string) (database.SavedSearch, error) {
+	search, err := s.db.GetSavedSearchByName(context.Background(), database.GetSavedSearchByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return search, fmt.Errorf("no saved search named %q", name)
+		}
+		return search, fmt.Errorf("couldn't look up saved search: %w", err)
+	}
+	return search, nil
+}
+
+// describeSavedSearch shows a saved search as the browse flags it stands for,
+// with its feeds under their current names.
+func describeSavedSearch(s *state, search database.SavedSearch) (string, error) {
+	var flags []string
+	for _, f := range []struct {
+		name  string
+		value sql.NullString
+	}{
+		{"keyword", search.Keyword},
+		{"author", search.Author},
+	} {
+		if f.value.Valid {
+			flags = append(flags, fmt.Sprintf("--%s %q", f.name, f.value.String))
+		}
+	}
+	if len(search.FeedIds) > 0 {
+		feeds, err := s.db.GetFeedsByIDs(context.Background(), search.FeedIds)
+		if err != nil {
+			return "", fmt.Errorf("couldn't get feeds for %s: %w", search.Name, err)
+		}
+		for _, feed := range feeds {
+			flags = append(flags, fmt.Sprintf("--feed %q", feed.Name))
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value sql.NullString
+	}{
+		{"folder", search.Folder},
+		{"tag", search.Tag},
+		{"since", search.Since},
+		{"until", search.Until},
+	} {
+		if f.value.Valid {
+			flags = append(flags, fmt.Sprintf("--%s %q", f.name, f.value.String))
+		}
+	}
+	return strings.Join(flags, " "), nil
+}
+
+// applySavedSearch fills in the browse filters a saved search sets, leaving
+// the ones already given on the command line alone. A relative --since is
+// worked out from now, so the window moves along with time.
+func applySavedSearch(search database.SavedSearch, params *database.BrowsePostsParams, now time.Time) error {
+	for _, f := range []struct {
+		param *sql.NullString
+		value sql.NullString
+	}{
+		{&params.Keyword, search.Keyword},
+		{&params.Author, search.Author},
+		{&params.Folder, search.Folder},
+		{&params.Tag, search.Tag},
+	} {
+		if !f.param.Valid {
+			*f.param = f.value
+		}
+	}
+	if len(params.FeedIds) == 0 {
+		params.FeedIds = search.FeedIds
+	}
+	for _, f := range []struct {
+		param *sql.NullTime
+		value sql.NullString
+	}{
+		{&params.Since, search.Since},
+		{&params.Until, search.Until},
+	} {
+		if !f.value.Valid || f.param.Valid {
+			continue
+		}
+		t, err := parseBrowseTime(f.value.String, now)
+		if err != nil {
+			return fmt.Errorf("saved search %s: %w", search.Name, err)
+		}
+		*f.param = sql.NullTime{Time: t, Valid: true}
+	}
+	return nil
+}
+
+func savedSearchUnread(s *state, user database.User, search database.SavedSearch) (int64, error) {
+	var params database.BrowsePostsParams
+	if err := applySavedSearch(search, &params, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+	n, err := s.db.CountUnreadPosts(context.Background(), database.CountUnreadPostsParams{
+		UserID:  user.ID,
+		FeedIds: params.FeedIds,
+		Folder:  params.Folder,
+		Since:   params.Since,
+		Until:   params.Until,
+		Author:  params.Author,
+		Keyword: params.Keyword,
+		Tag:     params.Tag,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("couldn't count unread posts for %s: %w", search.Name, err)
+	}
+	return n, nil
+}
+
+// The RSS 2.0 document written when exporting a saved search. RSSFeed is
+// shaped for reading the many variants found in the wild, so writing uses
+// these plainer types.
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []rssEntry `xml:"item"`
+}
+
+type rssEntry struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description,omitempty"`
+	PubDate     string   `xml:"pubDate"`
+	GUID        string   `xml:"guid"`
+	Categories  []string `xml:"category"`
+}
+
+// exportSavedSearchRSS renders the latest posts of a saved search, read or
+// not, as an RSS feed.
+func exportSavedSearchRSS(s *state, user database.User, name string) ([]byte, int, error) {
+	search, err := lookupSavedSearch(s, user, name)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now().UTC()
+	params := database.BrowsePostsParams{
+		UserID: user.ID,
+		Sort:   sortPublished,
+		Limit:  savedSearchExportLimit,
+	}
+	if err := applySavedSearch(search, &params, now); err != nil {
+		return nil, 0, err
+	}
+	posts, err := s.db.BrowsePosts(context.Background(), params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't get posts for %s: %w", search.Name, err)
+	}
+
+	description, err := describeSavedSearch(s, search)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	doc := rssDocument{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         search.Name,
+			Description:   fmt.Sprintf("%s's saved search %s in gator: %s", user.Name, search.Name, description),
+			LastBuildDate: now.Format(time.RFC1123Z),
+		},
+	}
+	for _, post := range posts {
+		doc.Channel.Items = append(doc.Channel.Items, rssEntry{
+			Title:       post.Title,
+			Link:        post.Url,
+			Description: post.Description.String,
+			PubDate:     post.SortTime.Format(time.RFC1123Z),
+			GUID:        post.Url,
+			Categories:  post.Tags,
+		})
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, 0, fmt.Errorf("couldn't encode RSS: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), len(posts), nil
+}
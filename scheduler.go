@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	jobStatusOK    = "ok"
+	jobStatusError = "error"
+
+	schedulerTick = 30 * time.Second
+)
+
+// maintenanceJob does one run of a periodic job and returns a one-line
+// summary of what it did, which is stored in the jobs table.
+type maintenanceJob func(ctx context.Context, s *state) (string, error)
+
+var maintenanceJobs = map[string]maintenanceJob{
+	"purge":       purgeJob,
+	"digest":      digestJob,
+	"deadlinks":   deadLinksJob,
+	"vacuumstats": vacuumStatsJob,
+}
+
+type scheduledJob struct {
+	name     string
+	schedule cronSchedule
+	run      maintenanceJob
+}
+
+// loadSchedule validates the jobs section of the config.
+func loadSchedule(jobs map[string]string) ([]scheduledJob, error) {
+	var scheduled []scheduledJob
+	for name, expr := range jobs {
+		run, ok := maintenanceJobs[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown job %q in config", name)
+		}
+		schedule, err := parseCron(expr)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", name, err)
+		}
+		scheduled = append(scheduled, scheduledJob{name: name, schedule: schedule, run: run})
+	}
+	sort.Slice(scheduled, func(i, j int) bool { return scheduled[i].name < scheduled[j].name })
+	return scheduled, nil
+}
+
+func nextRunAt(schedule cronSchedule, after time.Time) sql.NullTime {
+	next := schedule.next(after.Local())
+	if next.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: next.UTC(), Valid: true}
+}
+
+// runScheduler runs due jobs until ctx is cancelled. Schedules and results
+// live in the jobs table, so whichever agg instance is leader picks up
+// where the previous one left off.
+func runScheduler(ctx context.Context, s *state, jobs []scheduledJob, configs map[string]string) {
+	for _, job := range jobs {
+		err := s.db.UpsertJobSchedule(ctx, database.UpsertJobScheduleParams{
+			Name:      job.name,
+			Schedule:  configs[job.name],
+			NextRunAt: nextRunAt(job.schedule, time.Now()),
+		})
+		if err != nil {
+			slog.Error("couldn't register job", "job", job.name, "err", err)
+		}
+	}
+
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !s.instance.isLeader() {
+			continue
+		}
+		rows, err := s.db.ListJobs(ctx)
+		if err != nil {
+			slog.Error("couldn't list jobs", "err", err)
+			continue
+		}
+		due := map[string]bool{}
+		for _, row := range rows {
+			due[row.Name] = row.NextRunAt.Valid && !row.NextRunAt.Time.After(time.Now())
+		}
+		for _, job := range jobs {
+			if due[job.name] {
+				runJob(ctx, s, job)
+			}
+		}
+	}
+}
+
+func runJob(ctx context.Context, s *state, job scheduledJob) {
+	logger := slog.With("job", job.name)
+	logger.Info("running job")
+	start := time.Now()
+	result, err := job.run(ctx, s)
+	duration := time.Since(start)
+
+	status := jobStatusOK
+	if err != nil {
+		status = jobStatusError
+		result = err.Error()
+		logger.Error("job failed", "duration", duration, "err", err)
+	} else {
+		logger.Info("job finished", "duration", duration, "result", result)
+	}
+
+	err = s.db.RecordJobRun(ctx, database.RecordJobRunParams{
+		Name:           job.name,
+		LastRunAt:      sql.NullTime{Time: start.UTC(), Valid: true},
+		LastDurationMs: sql.NullInt64{Int64: duration.Milliseconds(), Valid: true},
+		LastStatus:     sql.NullString{String: status, Valid: true},
+		LastResult:     sql.NullString{String: result, Valid: true},
+		NextRunAt:      nextRunAt(job.schedule, time.Now()),
+	})
+	if err != nil {
+		logger.Error("couldn't record job run", "err", err)
+	}
+}
+
+func jobsHandler(s *state, cmd command) error {
+	jobs, err := s.db.ListJobs(context.Background())
+	if err != nil {
+		return fmt.Errorf("couldn't list jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No jobs have been scheduled yet. Add cron expressions under \"jobs\" in the config and run agg.")
+		return nil
+	}
+
+	for _, job := range jobs {
+		fmt.Printf("* %s (%s)\n", job.Name, job.Schedule)
+		if job.LastRunAt.Valid {
+			fmt.Printf("    Last run:  %s, %s in %dms\n", job.LastRunAt.Time.Local().Format(time.DateTime), job.LastStatus.String, job.LastDurationMs.Int64)
+			fmt.Printf("    Result:    %s\n", job.LastResult.String)
+		} else {
+			fmt.Println("    Last run:  never")
+		}
+		if job.NextRunAt.Valid {
+			fmt.Printf("    Next run:  %s\n", job.NextRunAt.Time.Local().Format(time.DateTime))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+func searchHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	limit := fs.Int("limit", 10, "maximum number of results")
+	offset := fs.Int("offset", 0, "number of results to skip")
+	page := fs.Int("page", 0, "page of results to show, starting at 1 (overrides --offset)")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage %s [--limit n] [--offset n | --page n] <query...>", cmd.Name)
+	}
+	if *limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	if *page > 0 {
+		*offset = (*page - 1) * *limit
+	}
+
+	query := strings.Join(fs.Args(), " ")
+	results, err := s.db.SearchPosts(context.Background(), database.SearchPostsParams{
+		Query:  query,
+		UserID: user.ID,
+		Limit:  int32(*limit),
+		Offset: int32(*offset),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't search posts: %w", err)
+	}
+	if len(results) == 0 {
+		fmt.Printf("No posts matching %q\n", query)
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(results))
+	for i, post := range results {
+		ids[i] = post.ID
+	}
+	shortIDs, err := postShortIDs(s, user, ids)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Results %d-%d for %q:\n", *offset+1, *offset+len(results), query)
+	for _, post := range results {
+		fmt.Printf("%s from %s (rank %.2f)\n", post.PublishedAt.Time.Format("Mon Jan 2"), post.FeedName, post.Rank)
+		fmt.Printf("--- %s ---\n", post.Title)
+		fmt.Printf("    %s\n", plainText(post.Snippet))
+		fmt.Printf("Link: %s\n", post.Url)
+		fmt.Printf("ID:   %s\n", shortIDs[post.ID])
+		fmt.Println("=====================================")
+	}
+	if len(results) == *limit {
+		fmt.Printf("More results may be available with --offset %d\n", *offset+*limit)
+	}
+	return nil
+}
@@ -22,17 +22,52 @@ INNER JOIN feeds ON inserted_feed_follow.feed_id = feeds.id
 INNER JOIN users ON inserted_feed_follow.user_id = users.id;
 --
 
+-- name: UpsertFeedFollow :one
+-- Following an already followed feed only moves it into folder_id, when one
+-- is given. No row comes back when nothing changed.
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, folder_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, feed_id) DO UPDATE
+SET folder_id = EXCLUDED.folder_id,
+updated_at = EXCLUDED.updated_at
+WHERE EXCLUDED.folder_id IS NOT NULL
+AND feed_follows.folder_id IS DISTINCT FROM EXCLUDED.folder_id
+RETURNING (xmax = 0) AS inserted;
+
+-- name: GetFeedByID :one
+select * from feeds where id=$1;
+
+-- name: GetFeedsByIDs :many
+SELECT * FROM feeds WHERE id = ANY(@ids::uuid[]) ORDER BY name;
+
+-- name: GetFollowedFeedIDsByName :many
+-- Feeds are matched by name, URL or the name the user gave them, like
+-- browse --feed does.
+SELECT feeds.id FROM feeds
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = @user_id
+AND @name::text IN (feeds.name, feeds.url, feed_follows.display_name)
+ORDER BY feeds.name;
+
 -- name: GetFeedByURL :one
 select * from feeds where url=$1; 
 
 -- name: GetFeedFollowsForUser :many
-SELECT feed_follows.*, feeds.name AS feed_name, users.name AS user_name
+SELECT feed_follows.*, feeds.name AS feed_name, feeds.url AS feed_url, feeds.site_url,
+users.name AS user_name, folders.name AS folder_name
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feeds.user_id = users.id
-WHERE feed_follows.user_id = $1;
+LEFT JOIN folders ON feed_follows.folder_id = folders.id
+WHERE feed_follows.user_id = $1
+ORDER BY folders.name NULLS FIRST, COALESCE(feed_follows.display_name, feeds.name);
 --
 
+-- name: SetFeedFollowDisplay :execrows
+UPDATE feed_follows
+SET display_name = $3, color = $4, emoji = $5, updated_at = $6
+WHERE user_id = $1 AND feed_id = $2;
+
 -- name: DeleteFeedFollow :exec
 delete from feed_follows where user_id=$1 and feed_id=$2;
 
@@ -43,22 +78,188 @@ updated_at = NOW()
 where id=$1
 returning *;
 
--- name: GetNextFeedToFetch :one
+-- name: GetFeedsDueForFetch :many
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
-LIMIT 1;
+WHERE status = 'active'
+AND (last_fetched_at IS NULL OR last_fetched_at < NOW() - make_interval(secs => @min_age_seconds::float8))
+ORDER BY last_fetched_at ASC NULLS FIRST;
 
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING *;
+-- name: CountFeedsDueForFetch :one
+SELECT COUNT(*) FROM feeds
+WHERE status = 'active'
+AND (last_fetched_at IS NULL OR last_fetched_at < NOW() - make_interval(secs => @min_age_seconds::float8));
+
+-- name: RecordFeedFailure :one
+-- A failure without an HTTP status, such as a timeout, leaves a run of 404s
+-- unbroken; any other status ends it.
+update feeds
+set last_error = $2,
+last_status_code = $3,
+failing_since = COALESCE(failing_since, $4),
+not_found_since = CASE
+    WHEN $3 = 404 THEN COALESCE(not_found_since, $4)
+    WHEN $3 IS NULL THEN not_found_since
+    ELSE NULL
+END,
+updated_at = NOW()
+where id=$1
+returning *;
+
+-- name: RecordFeedSuccess :exec
+update feeds
+set last_error = NULL,
+last_status_code = NULL,
+failing_since = NULL,
+not_found_since = NULL,
+site_url = $2
+where id=$1;
+
+-- name: SetFeedStatus :one
+update feeds
+set status = $2,
+updated_at = NOW()
+where id=$1
+returning *;
+
+-- name: GetFeedsNeedingAttention :many
+SELECT feeds.* FROM feeds
+INNER JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND (feeds.status <> 'active' OR feeds.failing_since IS NOT NULL)
+ORDER BY feeds.status DESC, feeds.failing_since ASC;
+
+-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, content, author, categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (url) DO UPDATE
+SET title = EXCLUDED.title,
+description = EXCLUDED.description,
+published_at = EXCLUDED.published_at,
+content = EXCLUDED.content,
+author = EXCLUDED.author,
+categories = EXCLUDED.categories,
+updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+OR posts.published_at IS DISTINCT FROM EXCLUDED.published_at
+OR posts.content IS DISTINCT FROM EXCLUDED.content
+OR posts.author IS DISTINCT FROM EXCLUDED.author
+OR posts.categories IS DISTINCT FROM EXCLUDED.categories
+RETURNING (xmax = 0) AS inserted;
 --
 
--- name: GetPostsForUser :many
-SELECT posts.*, feeds.name AS feed_name FROM posts
+-- name: BrowsePosts :many
+SELECT posts.*, COALESCE(feed_follows.display_name, feeds.name) AS feed_name, feeds.url AS feed_url,
+feed_follows.color AS feed_color, feed_follows.emoji AS feed_emoji,
+EXISTS (
+    SELECT 1 FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = @user_id
+) AS read,
+EXISTS (
+    SELECT 1 FROM saved_posts
+    WHERE saved_posts.post_id = posts.id AND saved_posts.user_id = @user_id
+) AS saved,
+ARRAY(
+    SELECT tags.name FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.user_id = @user_id
+    ORDER BY tags.name
+)::text[] AS tags,
+(CASE WHEN @sort::text = 'fetched' THEN posts.created_at
+    ELSE COALESCE(posts.published_at, posts.created_at) END)::timestamp AS sort_time
+FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON posts.feed_id = feeds.id
-WHERE feed_follows.user_id = $1
-ORDER BY posts.published_at DESC
-LIMIT $2;
+WHERE feed_follows.user_id = @user_id
+AND browse_filter_matches(@user_id, posts, feed_follows,
+    sqlc.narg('feed_ids')::uuid[], sqlc.narg('folder')::text,
+    sqlc.narg('since')::timestamp, sqlc.narg('until')::timestamp,
+    sqlc.narg('author')::text, sqlc.narg('keyword')::text, sqlc.narg('tag')::text)
+AND (NOT @unread_only::bool OR NOT EXISTS (
+    SELECT 1 FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = @user_id
+))
+AND (NOT @saved_only::bool OR EXISTS (
+    SELECT 1 FROM saved_posts
+    WHERE saved_posts.post_id = posts.id AND saved_posts.user_id = @user_id
+))
+AND (sqlc.narg('after_time')::timestamp IS NULL OR (
+    CASE WHEN @sort = 'feed' THEN
+        COALESCE(feed_follows.display_name, feeds.name) > sqlc.narg('after_feed')::text
+        OR (COALESCE(feed_follows.display_name, feeds.name) = sqlc.narg('after_feed')
+            AND (COALESCE(posts.published_at, posts.created_at), posts.id)
+                < (sqlc.narg('after_time'), sqlc.narg('after_id')::uuid))
+    ELSE
+        ((CASE WHEN @sort = 'fetched' THEN posts.created_at
+            ELSE COALESCE(posts.published_at, posts.created_at) END), posts.id)
+            < (sqlc.narg('after_time'), sqlc.narg('after_id')::uuid)
+    END
+))
+ORDER BY
+    CASE WHEN @sort = 'feed' THEN COALESCE(feed_follows.display_name, feeds.name) END ASC,
+    sort_time DESC,
+    posts.id DESC
+LIMIT sqlc.arg('limit');
 --
+
+-- name: GetPostByID :one
+SELECT posts.*, feeds.name AS feed_name
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = $1;
+
+-- name: GetPostIDsByPrefix :many
+-- A prefix is looked up as the range of IDs that start with it, so the
+-- primary key index can be used. Only posts in feeds the user follows count.
+SELECT posts.id FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+AND posts.id BETWEEN @low::uuid AND @high::uuid
+ORDER BY posts.id
+LIMIT 2;
+
+-- name: GetPostIDsInRanges :many
+SELECT posts.id FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN unnest(@lows::uuid[], @highs::uuid[]) AS ranges (low, high)
+ON posts.id BETWEEN ranges.low AND ranges.high
+WHERE feed_follows.user_id = @user_id
+ORDER BY posts.id;
+
+-- name: GetFeedStats :one
+SELECT
+    (SELECT COUNT(*) FROM feed_follows WHERE feed_follows.feed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM posts WHERE posts.feed_id = $1) AS posts,
+    (SELECT MAX(posts.created_at) FROM posts WHERE posts.feed_id = $1)::timestamp AS latest_post_at;
+
+-- name: RenameFeed :one
+UPDATE feeds
+SET name = $2, updated_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFeedRetention :one
+UPDATE feeds
+SET retain_days = $2, retain_posts = $3, updated_at = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFeedURL :one
+UPDATE feeds
+SET url = $2,
+updated_at = $3,
+status = 'active',
+last_error = NULL,
+last_status_code = NULL,
+failing_since = NULL,
+not_found_since = NULL,
+site_url = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: CountOtherFeedFollowers :one
+SELECT COUNT(*) FROM feed_follows
+WHERE feed_id = $1 AND user_id <> $2;
+
+-- name: DeleteFeed :execrows
+DELETE FROM feeds WHERE id = $1;
@@ -0,0 +1,25 @@
+-- name: CreateFilter :one
+INSERT INTO filters (id, created_at, user_id, feed_id, kind, pattern)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetFiltersForUser :many
+SELECT filters.*, feeds.name AS feed_name
+FROM filters
+LEFT JOIN feeds ON feeds.id = filters.feed_id
+WHERE filters.user_id = $1
+ORDER BY filters.created_at;
+
+-- name: DeleteFilter :execrows
+DELETE FROM filters WHERE id = $1 AND user_id = $2;
+
+-- name: GetMatchingFilters :many
+SELECT filters.*
+FROM filters, posts
+WHERE filters.user_id = $1
+AND posts.id = $2
+AND filter_matches(filters, posts)
+ORDER BY filters.created_at;
+
+-- name: ValidateRegex :exec
+SELECT ''::text ~* @pattern::text;
@@ -0,0 +1,35 @@
+-- name: GetOrCreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: CreateFolder :one
+INSERT INTO folders (id, created_at, updated_at, user_id, name)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetFolderByName :one
+SELECT * FROM folders WHERE user_id = $1 AND name = $2;
+
+-- name: RenameFolder :execrows
+UPDATE folders
+SET name = @new_name, updated_at = @updated_at
+WHERE user_id = @user_id AND name = @name;
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders WHERE user_id = $1 AND name = $2;
+
+-- name: GetFoldersForUser :many
+SELECT folders.*, COUNT(feed_follows.id) AS feed_count
+FROM folders
+LEFT JOIN feed_follows ON feed_follows.folder_id = folders.id
+WHERE folders.user_id = $1
+GROUP BY folders.id
+ORDER BY folders.name;
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE feed_follows
+SET folder_id = $3, updated_at = $4
+WHERE user_id = $1 AND feed_id = $2;
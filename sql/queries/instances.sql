@@ -0,0 +1,68 @@
+-- name: RegisterInstance :one
+INSERT INTO agg_instances (id, hostname, pid, started_at, heartbeat_at)
+VALUES ($1, $2, $3, NOW(), NOW())
+RETURNING *;
+
+-- name: HeartbeatInstance :exec
+-- An instance that missed its heartbeats for too long was removed by the
+-- others, so it registers again.
+INSERT INTO agg_instances (id, hostname, pid, started_at, heartbeat_at)
+VALUES ($1, $2, $3, NOW(), NOW())
+ON CONFLICT (id) DO UPDATE
+SET heartbeat_at = EXCLUDED.heartbeat_at;
+
+-- name: DeleteInstance :exec
+DELETE FROM agg_instances WHERE id = $1;
+
+-- name: DeleteStaleInstances :execrows
+DELETE FROM agg_instances
+WHERE heartbeat_at < NOW() - make_interval(secs => @ttl_seconds::float8);
+
+-- name: ClaimNextFeedToFetch :one
+UPDATE feeds
+SET claimed_by = @instance_id,
+claimed_until = NOW() + make_interval(secs => @claim_seconds::float8),
+last_fetched_at = NOW(),
+updated_at = NOW()
+WHERE id = (
+  SELECT id FROM feeds
+  WHERE status = 'active'
+  AND (claimed_by IS NULL OR claimed_until < NOW())
+  ORDER BY last_fetched_at ASC NULLS FIRST
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ClaimFeed :one
+-- ClaimFeed claims one feed, whatever its status. No row comes back while
+-- another live instance holds it.
+UPDATE feeds
+SET claimed_by = @instance_id,
+claimed_until = NOW() + make_interval(secs => @claim_seconds::float8)
+WHERE id = @id
+AND (claimed_by IS NULL OR claimed_until < NOW() OR claimed_by = @instance_id)
+RETURNING *;
+
+-- name: ExtendFeedClaims :exec
+UPDATE feeds
+SET claimed_until = NOW() + make_interval(secs => @claim_seconds::float8)
+WHERE claimed_by = @instance_id;
+
+-- name: ReleaseFeedClaim :exec
+UPDATE feeds
+SET claimed_by = NULL,
+claimed_until = NULL
+WHERE id = $1 AND claimed_by = $2;
+
+-- name: AcquireLease :one
+INSERT INTO leases (name, holder, expires_at)
+VALUES (@name, @holder, NOW() + make_interval(secs => @ttl_seconds::float8))
+ON CONFLICT (name) DO UPDATE
+SET holder = EXCLUDED.holder,
+expires_at = EXCLUDED.expires_at
+WHERE leases.holder = EXCLUDED.holder OR leases.expires_at < NOW()
+RETURNING holder;
+
+-- name: ReleaseLease :exec
+DELETE FROM leases WHERE name = $1 AND holder = $2;
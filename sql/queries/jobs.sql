@@ -0,0 +1,81 @@
+-- name: UpsertJobSchedule :exec
+INSERT INTO jobs (name, schedule, next_run_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (name) DO UPDATE
+SET schedule = EXCLUDED.schedule,
+next_run_at = CASE
+  WHEN jobs.schedule = EXCLUDED.schedule AND jobs.next_run_at IS NOT NULL THEN jobs.next_run_at
+  ELSE EXCLUDED.next_run_at
+END;
+
+-- name: ListJobs :many
+SELECT * FROM jobs ORDER BY name;
+
+-- name: RecordJobRun :exec
+UPDATE jobs
+SET last_run_at = $2,
+last_duration_ms = $3,
+last_status = $4,
+last_result = $5,
+next_run_at = $6
+WHERE name = $1;
+
+-- name: GetPostIDsToPurge :many
+-- GetPostIDsToPurge lists the posts that are past their feed's retention,
+-- in ID order. A feed's retain_days and retain_posts override the defaults,
+-- 0 meaning no limit, and only feeds with a post limit are ranked. Saved
+-- posts are never purged.
+WITH ranked AS (
+    SELECT posts.id, COALESCE(feeds.retain_posts, @default_posts::int) AS retain_posts,
+    ROW_NUMBER() OVER (
+        PARTITION BY posts.feed_id
+        ORDER BY COALESCE(posts.published_at, posts.created_at) DESC, posts.id DESC
+    ) AS position
+    FROM posts
+    JOIN feeds ON feeds.id = posts.feed_id
+    WHERE COALESCE(feeds.retain_posts, @default_posts::int) > 0
+)
+SELECT posts.id FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE COALESCE(feeds.retain_days, @default_days::int) > 0
+AND COALESCE(posts.published_at, posts.created_at)
+    < @now::timestamp - make_interval(days => COALESCE(feeds.retain_days, @default_days::int))
+AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+UNION
+SELECT ranked.id FROM ranked
+WHERE ranked.position > ranked.retain_posts
+AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = ranked.id)
+ORDER BY id;
+
+-- name: GetPostsToPurge :many
+SELECT posts.id, posts.feed_id, feeds.url AS feed_url, posts.title, posts.url, posts.description,
+posts.content, posts.author, posts.categories, posts.published_at, posts.created_at, posts.updated_at
+FROM posts
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE posts.id = ANY(@ids::uuid[])
+ORDER BY posts.id;
+
+-- name: DeletePosts :execrows
+DELETE FROM posts
+WHERE id = ANY(@ids::uuid[])
+AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id);
+
+-- name: GetPostsForDigest :many
+SELECT posts.title, posts.url, posts.published_at, feeds.name AS feed_name FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = $1 AND posts.created_at >= $2
+ORDER BY feeds.name, posts.published_at DESC;
+
+-- name: GetRecentPostURLs :many
+SELECT id, url FROM posts
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: GetTableVacuumStats :many
+SELECT relname::text AS table_name,
+  COALESCE(n_live_tup, 0)::bigint AS live_tuples,
+  COALESCE(n_dead_tup, 0)::bigint AS dead_tuples,
+  GREATEST(last_vacuum, last_autovacuum)::timestamp AS last_vacuumed
+FROM pg_stat_user_tables
+ORDER BY n_dead_tup DESC;
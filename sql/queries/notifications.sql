@@ -0,0 +1,22 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications (id, created_at, user_id, post_id, rule_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: GetNotificationsForUser :many
+SELECT notifications.id, notifications.created_at, notifications.seen_at,
+posts.id AS post_id, posts.title, posts.url,
+COALESCE(feed_follows.display_name, feeds.name) AS feed_name
+FROM notifications
+JOIN posts ON posts.id = notifications.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = notifications.user_id
+WHERE notifications.user_id = @user_id
+AND (@include_seen::boolean OR notifications.seen_at IS NULL)
+ORDER BY notifications.created_at DESC
+LIMIT @limit;
+
+-- name: MarkNotificationsSeen :execrows
+UPDATE notifications
+SET seen_at = $2
+WHERE user_id = $1 AND seen_at IS NULL;
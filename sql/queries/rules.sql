@@ -0,0 +1,38 @@
+-- name: CreateRule :one
+INSERT INTO rules (id, created_at, user_id, feed_id, title_pattern, content_pattern, tag_id, star, mark_read, notify)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetRulesForUser :many
+SELECT rules.*, feeds.name AS feed_name, tags.name AS tag_name
+FROM rules
+LEFT JOIN feeds ON feeds.id = rules.feed_id
+LEFT JOIN tags ON tags.id = rules.tag_id
+WHERE rules.user_id = $1
+ORDER BY rules.created_at;
+
+-- name: DeleteRule :execrows
+DELETE FROM rules WHERE id = $1 AND user_id = $2;
+
+-- name: GetRulesMatchingPost :many
+-- Only users who follow the post's feed have their rules applied to it, and
+-- not when one of their filters mutes the post.
+SELECT rules.*
+FROM rules
+JOIN posts ON posts.id = $1
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = rules.user_id
+WHERE rule_matches(rules.feed_id, rules.title_pattern, rules.content_pattern, posts)
+AND NOT post_muted(rules.user_id, posts)
+ORDER BY rules.created_at;
+
+-- name: BacktestRule :many
+SELECT posts.id, posts.title, posts.url, posts.published_at,
+COALESCE(feed_follows.display_name, feeds.name) AS feed_name,
+COUNT(*) OVER () AS total
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+AND rule_matches(sqlc.narg('feed_id')::uuid, sqlc.narg('title_pattern')::text, sqlc.narg('content_pattern')::text, posts)
+ORDER BY COALESCE(posts.published_at, posts.created_at) DESC
+LIMIT @limit;
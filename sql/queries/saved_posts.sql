@@ -0,0 +1,22 @@
+-- name: SavePost :one
+INSERT INTO saved_posts (user_id, post_id, created_at, note)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET note = COALESCE(EXCLUDED.note, saved_posts.note)
+RETURNING *;
+
+-- name: UnsavePost :execrows
+DELETE FROM saved_posts WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetSavedPostsForUser :many
+SELECT posts.*, feeds.name AS feed_name, saved_posts.created_at AS saved_at, saved_posts.note
+FROM saved_posts
+JOIN posts ON posts.id = saved_posts.post_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE saved_posts.user_id = $1
+ORDER BY saved_posts.created_at DESC;
+
+-- name: SavePostIfNotSaved :exec
+INSERT INTO saved_posts (user_id, post_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, post_id) DO NOTHING;
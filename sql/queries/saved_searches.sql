@@ -0,0 +1,38 @@
+-- name: UpsertSavedSearch :one
+INSERT INTO saved_searches (id, created_at, updated_at, user_id, name, keyword, author, feed_ids, folder, tag, since, until)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (user_id, name) DO UPDATE
+SET keyword = EXCLUDED.keyword,
+author = EXCLUDED.author,
+feed_ids = EXCLUDED.feed_ids,
+folder = EXCLUDED.folder,
+tag = EXCLUDED.tag,
+since = EXCLUDED.since,
+until = EXCLUDED.until,
+updated_at = EXCLUDED.updated_at
+RETURNING *;
+
+-- name: GetSavedSearchByName :one
+SELECT * FROM saved_searches WHERE user_id = $1 AND name = $2;
+
+-- name: GetSavedSearchesForUser :many
+SELECT * FROM saved_searches WHERE user_id = $1 ORDER BY name;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches WHERE user_id = $1 AND name = $2;
+
+-- name: CountUnreadPosts :one
+-- CountUnreadPosts counts the unread posts BrowsePosts would list with the
+-- same filters, for the unread counts of saved searches.
+SELECT COUNT(*)
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = @user_id
+AND browse_filter_matches(@user_id, posts, feed_follows,
+    sqlc.narg('feed_ids')::uuid[], sqlc.narg('folder')::text,
+    sqlc.narg('since')::timestamp, sqlc.narg('until')::timestamp,
+    sqlc.narg('author')::text, sqlc.narg('keyword')::text, sqlc.narg('tag')::text)
+AND NOT EXISTS (
+    SELECT 1 FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = @user_id
+);
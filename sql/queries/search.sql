@@ -0,0 +1,14 @@
+-- name: SearchPosts :many
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feed_name,
+ts_rank(posts.search_vector, query) AS rank,
+ts_headline('english', coalesce(posts.content, posts.description, posts.title), query,
+    'StartSel=«, StopSel=», MaxFragments=2, MaxWords=20, MinWords=5') AS snippet
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id,
+websearch_to_tsquery('english', @query::text) query
+WHERE feed_follows.user_id = @user_id
+AND posts.search_vector @@ query
+AND NOT post_muted(@user_id, posts)
+ORDER BY rank DESC, posts.published_at DESC NULLS LAST
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
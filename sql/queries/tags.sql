@@ -0,0 +1,27 @@
+-- name: GetOrCreateTag :one
+INSERT INTO tags (id, created_at, user_id, name)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, name) DO UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: TagPost :exec
+INSERT INTO post_tags (post_id, tag_id, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (post_id, tag_id) DO NOTHING;
+
+-- name: UntagPost :execrows
+DELETE FROM post_tags
+USING tags
+WHERE post_tags.tag_id = tags.id
+AND post_tags.post_id = $1
+AND tags.user_id = $2
+AND tags.name = $3;
+
+-- name: GetTagsForUser :many
+SELECT tags.name, COUNT(post_tags.post_id) AS posts
+FROM tags
+LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1
+GROUP BY tags.id, tags.name
+ORDER BY tags.name;
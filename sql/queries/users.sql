@@ -18,3 +18,6 @@ DELETE FROM users;
 SELECT * FROM users;
 
 
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
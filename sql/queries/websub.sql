@@ -0,0 +1,35 @@
+-- name: UpsertWebSubSubscription :one
+INSERT INTO websub_subscriptions (id, created_at, updated_at, feed_id, hub_url, topic_url, secret)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (feed_id) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+hub_url = EXCLUDED.hub_url,
+topic_url = EXCLUDED.topic_url,
+status = 'pending'
+RETURNING *;
+
+-- name: GetWebSubSubscriptionByFeed :one
+SELECT * FROM websub_subscriptions WHERE feed_id = $1;
+
+-- name: ActivateWebSubSubscription :one
+UPDATE websub_subscriptions
+SET status = 'active',
+lease_expires_at = $2,
+updated_at = NOW()
+WHERE feed_id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: DenyWebSubSubscription :exec
+UPDATE websub_subscriptions
+SET status = 'denied',
+lease_expires_at = NULL,
+updated_at = NOW()
+WHERE feed_id = $1;
+
+-- name: GetWebSubSubscriptionsToRenew :many
+SELECT * FROM websub_subscriptions
+WHERE status = 'active' AND lease_expires_at < $1
+ORDER BY lease_expires_at ASC;
+
+-- name: DeleteWebSubSubscription :exec
+DELETE FROM websub_subscriptions WHERE feed_id = $1;
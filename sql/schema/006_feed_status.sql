@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'dead')),
+ADD COLUMN last_error TEXT,
+ADD COLUMN last_status_code INT,
+ADD COLUMN failing_since TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN status,
+DROP COLUMN last_error,
+DROP COLUMN last_status_code,
+DROP COLUMN failing_since;
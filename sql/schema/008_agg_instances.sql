@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE agg_instances (
+  id UUID PRIMARY KEY,
+  hostname TEXT NOT NULL,
+  pid INT NOT NULL,
+  started_at TIMESTAMP NOT NULL,
+  heartbeat_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE feeds
+ADD COLUMN claimed_by UUID REFERENCES agg_instances (id) ON DELETE SET NULL,
+ADD COLUMN claimed_until TIMESTAMP;
+
+CREATE TABLE leases (
+  name TEXT PRIMARY KEY,
+  holder UUID NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE leases;
+
+ALTER TABLE feeds
+DROP COLUMN claimed_by,
+DROP COLUMN claimed_until;
+
+DROP TABLE agg_instances;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE jobs (
+  name TEXT PRIMARY KEY,
+  schedule TEXT NOT NULL,
+  next_run_at TIMESTAMP,
+  last_run_at TIMESTAMP,
+  last_duration_ms BIGINT,
+  last_status TEXT,
+  last_result TEXT
+);
+
+-- +goose Down
+DROP TABLE jobs;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE saved_posts (
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL,
+  note TEXT,
+  PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE saved_posts;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN author TEXT;
+
+CREATE INDEX posts_feed_published_idx ON posts (feed_id, (COALESCE(published_at, created_at)) DESC, id DESC);
+
+-- +goose Down
+DROP INDEX posts_feed_published_idx;
+
+ALTER TABLE posts
+DROP COLUMN author;
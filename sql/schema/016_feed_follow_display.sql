@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feed_follows
+ADD COLUMN display_name TEXT,
+ADD COLUMN color TEXT,
+ADD COLUMN emoji TEXT;
+
+-- +goose Down
+ALTER TABLE feed_follows
+DROP COLUMN display_name,
+DROP COLUMN color,
+DROP COLUMN emoji;
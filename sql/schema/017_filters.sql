@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE filters (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  feed_id UUID REFERENCES feeds (id) ON DELETE CASCADE,
+  kind TEXT NOT NULL CHECK (kind IN ('keyword', 'regex', 'author', 'category')),
+  pattern TEXT NOT NULL
+);
+
+CREATE INDEX filters_user_idx ON filters (user_id);
+
+-- filter_matches holds the matching rules in one place so every query that
+-- hides muted posts agrees on what a filter matches.
+-- +goose StatementBegin
+CREATE FUNCTION filter_matches(f filters, p posts) RETURNS BOOLEAN AS $$
+  SELECT (f.feed_id IS NULL OR f.feed_id = p.feed_id)
+  AND COALESCE(CASE f.kind
+    WHEN 'keyword' THEN p.title ILIKE '%' || f.pattern || '%'
+      OR p.description ILIKE '%' || f.pattern || '%'
+    WHEN 'regex' THEN p.title ~* f.pattern OR p.description ~* f.pattern
+    WHEN 'author' THEN p.author ILIKE '%' || f.pattern || '%'
+    WHEN 'category' THEN EXISTS (
+      SELECT 1 FROM unnest(p.categories) AS category
+      WHERE lower(category) = lower(f.pattern)
+    )
+  END, FALSE)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION filter_matches(filters, posts);
+
+DROP TABLE filters;
+
+ALTER TABLE posts
+DROP COLUMN categories;
@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE rules (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  feed_id UUID REFERENCES feeds (id) ON DELETE CASCADE,
+  title_pattern TEXT,
+  content_pattern TEXT,
+  tag_id UUID REFERENCES tags (id) ON DELETE CASCADE,
+  star BOOLEAN NOT NULL DEFAULT FALSE,
+  mark_read BOOLEAN NOT NULL DEFAULT FALSE,
+  notify BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX rules_user_idx ON rules (user_id);
+
+CREATE TABLE notifications (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  post_id UUID NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+  rule_id UUID REFERENCES rules (id) ON DELETE SET NULL,
+  seen_at TIMESTAMP,
+  UNIQUE (user_id, post_id)
+);
+
+-- rule_matches takes a rule's conditions rather than a rules row so that
+-- rules can be backtested before they're saved. A condition that is NULL
+-- matches every post.
+-- +goose StatementBegin
+CREATE FUNCTION rule_matches(feed_id UUID, title_pattern TEXT, content_pattern TEXT, p posts) RETURNS BOOLEAN AS $$
+  SELECT (feed_id IS NULL OR feed_id = p.feed_id)
+  AND (title_pattern IS NULL OR p.title ~* title_pattern)
+  AND (content_pattern IS NULL
+    OR COALESCE(p.description, '') ~* content_pattern
+    OR COALESCE(p.content, '') ~* content_pattern)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION rule_matches(UUID, TEXT, TEXT, posts);
+
+DROP TABLE notifications;
+
+DROP TABLE rules;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE saved_searches (
+  id UUID PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  keyword TEXT,
+  author TEXT,
+  feed TEXT,
+  folder TEXT,
+  tag TEXT,
+  -- since is kept as typed, e.g. 7d, so the window moves with time.
+  since TEXT,
+  UNIQUE (user_id, name)
+);
+
+-- +goose Down
+DROP TABLE saved_searches;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Per-feed overrides of the global post_retention_days and
+-- post_retention_posts settings. NULL uses the global value and 0 disables
+-- that limit for the feed.
+ALTER TABLE feeds
+ADD COLUMN retain_days INTEGER CHECK (retain_days >= 0),
+ADD COLUMN retain_posts INTEGER CHECK (retain_posts >= 0);
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN retain_days,
+DROP COLUMN retain_posts;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- not_found_since is when the feed's current run of 404 responses began,
+-- separate from failing_since, which any kind of failure starts.
+ALTER TABLE feeds
+ADD COLUMN not_found_since TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN not_found_since;
@@ -0,0 +1,53 @@
+-- +goose Up
+-- Keyword and author filters now match their pattern as plain text, so a %
+-- or _ in it isn't taken as a LIKE wildcard.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_matches(f filters, p posts) RETURNS BOOLEAN AS $$
+  SELECT (f.feed_id IS NULL OR f.feed_id = p.feed_id)
+  AND COALESCE(CASE f.kind
+    WHEN 'keyword' THEN strpos(lower(p.title), lower(f.pattern)) > 0
+      OR strpos(lower(p.description), lower(f.pattern)) > 0
+    WHEN 'regex' THEN p.title ~* f.pattern OR p.description ~* f.pattern
+    WHEN 'author' THEN strpos(lower(p.author), lower(f.pattern)) > 0
+    WHEN 'category' THEN EXISTS (
+      SELECT 1 FROM unnest(p.categories) AS category
+      WHERE lower(category) = lower(f.pattern)
+    )
+  END, FALSE)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- post_muted is what every query that hides muted posts checks: whether one
+-- of the user's filters matches the post. Posts the user saved are never
+-- muted.
+-- +goose StatementBegin
+CREATE FUNCTION post_muted(for_user UUID, p posts) RETURNS BOOLEAN AS $$
+  SELECT NOT EXISTS (
+    SELECT 1 FROM saved_posts
+    WHERE saved_posts.user_id = for_user AND saved_posts.post_id = p.id
+  )
+  AND EXISTS (
+    SELECT 1 FROM filters
+    WHERE filters.user_id = for_user AND filter_matches(filters, p)
+  )
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION post_muted(UUID, posts);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION filter_matches(f filters, p posts) RETURNS BOOLEAN AS $$
+  SELECT (f.feed_id IS NULL OR f.feed_id = p.feed_id)
+  AND COALESCE(CASE f.kind
+    WHEN 'keyword' THEN p.title ILIKE '%' || f.pattern || '%'
+      OR p.description ILIKE '%' || f.pattern || '%'
+    WHEN 'regex' THEN p.title ~* f.pattern OR p.description ~* f.pattern
+    WHEN 'author' THEN p.author ILIKE '%' || f.pattern || '%'
+    WHEN 'category' THEN EXISTS (
+      SELECT 1 FROM unnest(p.categories) AS category
+      WHERE lower(category) = lower(f.pattern)
+    )
+  END, FALSE)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- +goose Up
+-- Saved searches keep the feeds they cover by ID, so renaming a feed doesn't
+-- break them, and they can cover several feeds. until is kept as typed,
+-- like since.
+ALTER TABLE saved_searches
+ADD COLUMN feed_ids UUID[],
+ADD COLUMN until TEXT;
+
+UPDATE saved_searches
+SET feed_ids = ARRAY(
+  SELECT feeds.id FROM feeds
+  JOIN feed_follows ON feed_follows.feed_id = feeds.id
+  WHERE feed_follows.user_id = saved_searches.user_id
+  AND saved_searches.feed IN (feeds.name, feeds.url, feed_follows.display_name)
+)
+WHERE feed IS NOT NULL;
+
+ALTER TABLE saved_searches
+DROP COLUMN feed;
+
+-- browse_filter_matches holds the filters that browse and saved searches
+-- share, so listing a saved search and counting its unread posts always
+-- agree. A NULL filter matches every post; muted posts never match.
+-- +goose StatementBegin
+CREATE FUNCTION browse_filter_matches(
+  for_user UUID, p posts, ff feed_follows,
+  feed_ids UUID[], folder TEXT, since TIMESTAMP, until TIMESTAMP,
+  author TEXT, keyword TEXT, tag TEXT
+) RETURNS BOOLEAN AS $$
+  SELECT (feed_ids IS NULL OR p.feed_id = ANY (feed_ids))
+  AND (folder IS NULL OR ff.folder_id = (
+    SELECT folders.id FROM folders
+    WHERE folders.user_id = for_user AND folders.name = folder
+  ))
+  AND (since IS NULL OR COALESCE(p.published_at, p.created_at) >= since)
+  AND (until IS NULL OR COALESCE(p.published_at, p.created_at) < until)
+  AND (author IS NULL OR strpos(lower(p.author), lower(author)) > 0)
+  AND (keyword IS NULL
+    OR strpos(lower(p.title), lower(keyword)) > 0
+    OR strpos(lower(p.description), lower(keyword)) > 0)
+  AND (tag IS NULL OR EXISTS (
+    SELECT 1 FROM post_tags
+    JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = p.id AND tags.user_id = for_user AND tags.name = tag
+  ))
+  AND NOT post_muted(for_user, p)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION browse_filter_matches(UUID, posts, feed_follows, UUID[], TEXT, TIMESTAMP, TIMESTAMP, TEXT, TEXT, TEXT);
+
+ALTER TABLE saved_searches
+ADD COLUMN feed TEXT;
+
+UPDATE saved_searches
+SET feed = (SELECT feeds.url FROM feeds WHERE feeds.id = saved_searches.feed_ids[1]);
+
+ALTER TABLE saved_searches
+DROP COLUMN feed_ids,
+DROP COLUMN until;
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// aggStatus tracks what a running aggregator is doing for the status
+// endpoint. A nil *aggStatus ignores updates, so one-shot fetches don't
+// need to set it up.
+type aggStatus struct {
+	mu          sync.Mutex
+	startedAt   time.Time
+	lastSuccess *feedRun
+	lastFailure *feedRun
+	inFlight    map[uuid.UUID]feedRun
+}
+
+type feedRun struct {
+	FeedName  string    `json:"feed_name"`
+	FeedURL   string    `json:"feed_url"`
+	StartedAt time.Time `json:"started_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type statusReport struct {
+	PID           int       `json:"pid"`
+	InstanceID    string    `json:"instance_id,omitempty"`
+	Leader        bool      `json:"leader"`
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	LastSuccess   *feedRun  `json:"last_success,omitempty"`
+	LastFailure   *feedRun  `json:"last_failure,omitempty"`
+	InFlight      []feedRun `json:"in_flight"`
+}
+
+func newAggStatus() *aggStatus {
+	return &aggStatus{
+		startedAt: time.Now(),
+		inFlight:  map[uuid.UUID]feedRun{},
+	}
+}
+
+func (a *aggStatus) begin(feed database.Feed) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inFlight[feed.ID] = feedRun{FeedName: feed.Name, FeedURL: feed.Url, StartedAt: time.Now()}
+}
+
+func (a *aggStatus) end(feed database.Feed, err error) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	run := a.inFlight[feed.ID]
+	delete(a.inFlight, feed.ID)
+	if err != nil {
+		run.Error = err.Error()
+		a.lastFailure = &run
+		return
+	}
+	a.lastSuccess = &run
+}
+
+func (a *aggStatus) report(inst *aggInstance) statusReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	report := statusReport{
+		PID:           os.Getpid(),
+		StartedAt:     a.startedAt,
+		UptimeSeconds: time.Since(a.startedAt).Seconds(),
+		LastSuccess:   a.lastSuccess,
+		LastFailure:   a.lastFailure,
+		InFlight:      []feedRun{},
+	}
+	if inst != nil {
+		report.InstanceID = inst.id.String()
+		report.Leader = inst.isLeader()
+	}
+	for _, run := range a.inFlight {
+		report.InFlight = append(report.InFlight, run)
+	}
+	sort.Slice(report.InFlight, func(i, j int) bool {
+		return report.InFlight[i].StartedAt.Before(report.InFlight[j].StartedAt)
+	})
+	return report
+}
+
+// statusListen listens on a TCP address, or on a unix socket when addr
+// looks like "unix:/path/to/socket".
+func statusListen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		// A socket left behind by a crashed agg would make Listen fail, but
+		// one that still accepts connections belongs to a running agg.
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		switch {
+		case err == nil:
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another running agg", path)
+		case errors.Is(err, syscall.ECONNREFUSED):
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+func serveStatus(s *state, addr string) {
+	listener, err := statusListen(addr)
+	if err != nil {
+		slog.Error("couldn't listen for status requests", "addr", addr, "err", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("GET /status", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(rw).Encode(s.status.report(s.instance))
+	})
+	slog.Info("serving status", "addr", addr)
+	if err := http.Serve(listener, mux); err != nil {
+		slog.Error("status server stopped", "err", err)
+	}
+}
+
+// statusClient returns an HTTP client and base URL for talking to the status
+// endpoint at addr.
+func statusClient(addr string) (*http.Client, string) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+		return client, "http://gator"
+	}
+	return client, "http://" + addr
+}
+
+func statusHandler(s *state, cmd command) error {
+	if s.cfg.StatusAddr == "" {
+		return errors.New("status_addr is not set in the config")
+	}
+	client, baseURL := statusClient(s.cfg.StatusAddr)
+	res, err := client.Get(baseURL + "/status")
+	if err != nil {
+		return fmt.Errorf("couldn't reach the aggregator at %s, is agg running? %w", s.cfg.StatusAddr, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return &httpStatusError{StatusCode: res.StatusCode, URL: baseURL + "/status"}
+	}
+	var report statusReport
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		return fmt.Errorf("couldn't decode status: %w", err)
+	}
+
+	fmt.Printf("* PID:           %d\n", report.PID)
+	if report.InstanceID != "" {
+		fmt.Printf("* Instance:      %s (leader: %t)\n", report.InstanceID, report.Leader)
+	}
+	fmt.Printf("* Started:       %s\n", report.StartedAt.Format(time.DateTime))
+	fmt.Printf("* Uptime:        %s\n", (time.Duration(report.UptimeSeconds) * time.Second).String())
+	if report.LastSuccess != nil {
+		fmt.Printf("* Last success:  %s at %s\n", report.LastSuccess.FeedName, report.LastSuccess.StartedAt.Format(time.DateTime))
+	} else {
+		fmt.Println("* Last success:  none yet")
+	}
+	if report.LastFailure != nil {
+		fmt.Printf("* Last failure:  %s at %s: %s\n", report.LastFailure.FeedName, report.LastFailure.StartedAt.Format(time.DateTime), report.LastFailure.Error)
+	}
+	fmt.Printf("* In flight:     %d\n", len(report.InFlight))
+	for _, run := range report.InFlight {
+		fmt.Printf("    %s (%s) for %s\n", run.FeedName, run.FeedURL, time.Since(run.StartedAt).Round(time.Second))
+	}
+	return nil
+}
+
+// writePidfile records our PID at path, refusing to start if the file names
+// a process that is still running.
+func writePidfile(path string) error {
+	if data, err := os.ReadFile(path); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && processRunning(pid) {
+			return fmt.Errorf("agg is already running with pid %d (pidfile %s)", pid, path)
+		}
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+}
+
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
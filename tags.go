@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// tagNames trims the tag names given on the command line, which must not be
+// empty once trimmed.
+func tagNames(args []string) ([]string, error) {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = strings.TrimSpace(arg)
+		if names[i] == "" {
+			return nil, errors.New("tag names can't be empty")
+		}
+	}
+	return names, nil
+}
+
+func tagHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return fmt.Errorf("usage %s <post_id> <tag>...", cmd.Name)
+	}
+	postID, err := resolvePostID(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	short, err := postShortID(s, user, postID)
+	if err != nil {
+		return err
+	}
+	tags, err := tagNames(cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := database.New(instrumentedDB{db: tx})
+
+	for _, name := range tags {
+		tag, err := q.GetOrCreateTag(ctx, database.GetOrCreateTagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    user.ID,
+			Name:      name,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't create tag %q: %w", name, err)
+		}
+		err = q.TagPost(ctx, database.TagPostParams{
+			PostID:    postID,
+			TagID:     tag.ID,
+			CreatedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't tag post: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit tags: %w", err)
+	}
+	fmt.Printf("Post %s has been tagged %s\n", short, strings.Join(tags, ", "))
+	return nil
+}
+
+func untagHandler(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 2 {
+		return fmt.Errorf("usage %s <post_id> <tag>...", cmd.Name)
+	}
+	postID, err := resolvePostID(s, user, cmd.Args[0])
+	if err != nil {
+		return err
+	}
+	short, err := postShortID(s, user, postID)
+	if err != nil {
+		return err
+	}
+	tags, err := tagNames(cmd.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	// Nothing is removed unless the post had every tag given.
+	defer tx.Rollback()
+	q := database.New(instrumentedDB{db: tx})
+
+	for _, name := range tags {
+		n, err := q.UntagPost(ctx, database.UntagPostParams{
+			PostID: postID,
+			UserID: user.ID,
+			Name:   name,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't untag post: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("post %s is not tagged %s", short, name)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("couldn't commit tags: %w", err)
+	}
+	fmt.Printf("Removed %s from post %s\n", strings.Join(tags, ", "), short)
+	return nil
+}
+
+func tagsHandler(s *state, cmd command, user database.User) error {
+	tags, err := s.db.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get tags: %w", err)
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags found for this user.")
+		return nil
+	}
+	fmt.Printf("Tags for user %s:\n", user.Name)
+	for _, tag := range tags {
+		fmt.Printf("* %s (%d posts)\n", tag.Name, tag.Posts)
+	}
+	return nil
+}
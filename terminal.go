@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// stty runs stty against the controlling terminal and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// enterRawMode switches the terminal to raw mode without echo and returns
+// a function that restores the previous settings.
+func enterRawMode() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(saved)
+	}, nil
+}
+
+// terminalSize returns the number of columns and rows of the terminal.
+func terminalSize() (int, int, error) {
+	out, err := stty("size")
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected stty size output %q", out)
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	cols, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if rows == 0 || cols == 0 {
+		return 0, 0, errors.New("terminal size is unknown")
+	}
+	return cols, rows, nil
+}
+
+// isTerminal reports whether f is connected to a terminal rather than a
+// file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// openURL opens url in $BROWSER, falling back to the platform's default
+// handler. It doesn't wait for the browser to exit.
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	if browser := os.Getenv("BROWSER"); browser != "" {
+		cmd = exec.Command(browser, url)
+	} else {
+		switch runtime.GOOS {
+		case "darwin":
+			cmd = exec.Command("open", url)
+		case "windows":
+			cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+		default:
+			cmd = exec.Command("xdg-open", url)
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't open %s: %w", url, err)
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+	// htmlBlockPattern matches the tags that start or end a block of text,
+	// and line breaks.
+	htmlBlockPattern = regexp.MustCompile(`(?i)<(?:/?(?:p|div|li|ul|ol|h[1-6]|blockquote|pre|tr|table|hr)|br)\b[^>]*>`)
+	paragraphPattern = regexp.MustCompile(`\n\s*\n`)
+)
+
+// plainText strips HTML tags and entities from s and collapses whitespace,
+// which is good enough for showing feed descriptions in a terminal.
+func plainText(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(s, " "))
+}
+
+// plainParagraphs is plainText for longer text such as a post's content. It
+// keeps block boundaries (paragraphs, list items, headings, line breaks and
+// blank lines) as paragraph breaks.
+func plainParagraphs(s string) []string {
+	s = htmlBlockPattern.ReplaceAllString(s, "\n\n")
+	var paragraphs []string
+	for _, p := range paragraphPattern.Split(s, -1) {
+		if p = plainText(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+// wrapParagraphs wraps each paragraph to width with wrapText, with a blank
+// line between paragraphs.
+func wrapParagraphs(paragraphs []string, width int) []string {
+	var lines []string
+	for i, p := range paragraphs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, wrapText(p, width)...)
+	}
+	return lines
+}
+
+// wrapText breaks s into lines of at most width runes, splitting on spaces
+// and hard-breaking words that are longer than a line.
+func wrapText(s string, width int) []string {
+	if width <= 0 {
+		return nil
+	}
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = line[:0]
+		}
+		for len(w) > width {
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
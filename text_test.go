@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlainParagraphs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"plain text", "one line", []string{"one line"}},
+		{"blank lines", "first\n\nsecond\n  \nthird", []string{"first", "second", "third"}},
+		{"single newlines join", "first\nline", []string{"first line"}},
+		{"paragraphs", "<p>First  one.</p><p>Second &amp; last.</p>", []string{"First one.", "Second & last."}},
+		{"line breaks", "a<br>b<br/>c<BR />d", []string{"a", "b", "c", "d"}},
+		{"headings and lists", "<h2>Title</h2><ul><li>one</li><li>two</li></ul>", []string{"Title", "one", "two"}},
+		{"inline tags stay in the paragraph", "<p>a <b>bold</b> <a href=\"x\">link</a></p>", []string{"a bold link"}},
+		{"empty", "<p></p> <br>", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plainParagraphs(tt.in); !slices.Equal(got, tt.want) {
+				t.Errorf("plainParagraphs(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapParagraphs(t *testing.T) {
+	got := wrapParagraphs([]string{"one two three", "four"}, 8)
+	want := []string{"one two", "three", "", "four"}
+	if !slices.Equal(got, want) {
+		t.Errorf("wrapParagraphs = %q, want %q", got, want)
+	}
+}
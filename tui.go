@@ -0,0 +1,563 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	tuiPaneFeeds = iota
+	tuiPanePosts
+	tuiPaneReader
+)
+
+// tuiPostLimit caps how many posts the post list loads at once.
+const tuiPostLimit = 500
+
+// tuiFeed is a row of the feed pane: "All feeds", a folder, or a feed
+// (indented when it's inside a folder).
+type tuiFeed struct {
+	name     string
+	id       uuid.UUID
+	url      string
+	folder   string
+	isFolder bool
+	unread   int64
+}
+
+// key identifies the row across reloads.
+func (f tuiFeed) key() string {
+	if f.isFolder {
+		return "folder:" + f.folder
+	}
+	return f.url
+}
+
+// tui is the state of the interactive reader started by the tui command.
+// It only ever runs on one goroutine; keys and refresh ticks are delivered
+// to it over channels.
+type tui struct {
+	s    *state
+	user database.User
+
+	feeds   []tuiFeed
+	posts   []database.BrowsePostsRow
+	feedIdx int
+	postIdx int
+	feedTop int
+	postTop int
+
+	reader    []string
+	readerTop int
+
+	focus   int
+	showAll bool
+	message string
+
+	width  int
+	height int
+}
+
+func tuiHandler(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	refresh := fs.Duration("refresh", time.Minute, "how often to reload feeds and posts")
+	showAll := fs.Bool("all", false, "include posts that have already been read")
+	if err := fs.Parse(cmd.Args); err != nil {
+		return err
+	}
+	if *refresh <= 0 {
+		return errors.New("refresh interval must be positive")
+	}
+
+	t := &tui{s: s, user: user, showAll: *showAll, focus: tuiPanePosts}
+	if err := t.reload(); err != nil {
+		return err
+	}
+
+	restore, err := enterRawMode()
+	if err != nil {
+		return fmt.Errorf("couldn't set up the terminal: %w", err)
+	}
+	defer restore()
+	// Alternate screen, hidden cursor; undone in reverse on the way out.
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	go readKeys(keys)
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+
+	for {
+		t.render()
+		select {
+		case key, ok := <-keys:
+			if !ok || !t.handleKey(key) {
+				return nil
+			}
+		case <-ticker.C:
+			if err := t.reload(); err != nil {
+				t.message = err.Error()
+			}
+		}
+	}
+}
+
+// readKeys decodes raw terminal input into key names and sends them on
+// keys until stdin is closed.
+func readKeys(keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		in := buf[:n]
+		switch {
+		case len(in) >= 3 && in[0] == 0x1b && in[1] == '[':
+			switch in[2] {
+			case 'A':
+				keys <- "up"
+			case 'B':
+				keys <- "down"
+			case 'C':
+				keys <- "right"
+			case 'D':
+				keys <- "left"
+			case '5':
+				keys <- "pgup"
+			case '6':
+				keys <- "pgdown"
+			}
+		case in[0] == 0x1b:
+			keys <- "esc"
+		case in[0] == 0x03:
+			keys <- "ctrl-c"
+		case in[0] == '\r' || in[0] == '\n':
+			keys <- "enter"
+		case in[0] == '\t':
+			keys <- "tab"
+		default:
+			for _, r := range string(in) {
+				keys <- string(r)
+			}
+		}
+	}
+}
+
+// reload fetches the followed feeds and the posts of the selected feed,
+// keeping the current selection where possible.
+func (t *tui) reload() error {
+	ctx := context.Background()
+	follows, err := t.s.db.GetFeedFollowsForUser(ctx, t.user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+	counts, err := t.s.db.GetUnreadCountsForUser(ctx, t.user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't count unread posts: %w", err)
+	}
+	unread := make(map[uuid.UUID]int64, len(counts))
+	var total int64
+	for _, c := range counts {
+		unread[c.FeedID] = c.Unread
+		total += c.Unread
+	}
+
+	selected := ""
+	if t.feedIdx < len(t.feeds) {
+		selected = t.feeds[t.feedIdx].key()
+	}
+	t.feeds = append(t.feeds[:0], tuiFeed{name: "All feeds", unread: total})
+	// Follows come sorted with unfiled feeds first, then folder by folder.
+	folderRow := -1
+	for _, ff := range follows {
+		row := tuiFeed{name: ff.FeedName, id: ff.FeedID, url: ff.FeedUrl, unread: unread[ff.FeedID]}
+		if ff.DisplayName.Valid {
+			row.name = ff.DisplayName.String
+		}
+		if ff.Emoji.Valid {
+			row.name = ff.Emoji.String + " " + row.name
+		}
+		if ff.FolderName.Valid {
+			row.folder = ff.FolderName.String
+			if folderRow < 0 || t.feeds[folderRow].folder != row.folder {
+				t.feeds = append(t.feeds, tuiFeed{name: row.folder, folder: row.folder, isFolder: true})
+				folderRow = len(t.feeds) - 1
+			}
+			t.feeds[folderRow].unread += row.unread
+		}
+		t.feeds = append(t.feeds, row)
+	}
+	t.feedIdx = 0
+	for i, f := range t.feeds {
+		if i > 0 && f.key() == selected {
+			t.feedIdx = i
+			break
+		}
+	}
+	return t.loadPosts()
+}
+
+func (t *tui) loadPosts() error {
+	var selectedID uuid.UUID
+	if t.postIdx < len(t.posts) {
+		selectedID = t.posts[t.postIdx].ID
+	}
+	feed := t.feeds[t.feedIdx]
+	params := database.BrowsePostsParams{
+		UserID:     t.user.ID,
+		Sort:       sortPublished,
+		Folder:     sql.NullString{String: feed.folder, Valid: feed.isFolder},
+		UnreadOnly: !t.showAll,
+		Limit:      tuiPostLimit,
+	}
+	if feed.url != "" {
+		params.FeedIds = []uuid.UUID{feed.id}
+	}
+	posts, err := t.s.db.BrowsePosts(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't get posts for user: %w", err)
+	}
+	t.posts = posts
+	t.postIdx = 0
+	for i, post := range posts {
+		if post.ID == selectedID {
+			t.postIdx = i
+			break
+		}
+	}
+	return nil
+}
+
+// handleKey applies a key press and reports whether the TUI should keep
+// running.
+func (t *tui) handleKey(key string) bool {
+	t.message = ""
+	switch key {
+	case "q", "ctrl-c":
+		return false
+	case "tab", "l", "right":
+		if t.focus < tuiPaneReader {
+			t.focus++
+		}
+	case "h", "left", "esc":
+		if t.focus > tuiPaneFeeds {
+			t.focus--
+		}
+	case "j", "down":
+		t.move(1)
+	case "k", "up":
+		t.move(-1)
+	case "pgdown", " ":
+		t.move(t.paneHeight())
+	case "pgup":
+		t.move(-t.paneHeight())
+	case "enter":
+		switch t.focus {
+		case tuiPaneFeeds:
+			t.posts = nil
+			if err := t.loadPosts(); err != nil {
+				t.message = err.Error()
+			}
+			t.focus = tuiPanePosts
+		case tuiPanePosts:
+			t.openReader()
+		}
+	case "m":
+		t.toggleRead()
+	case "s":
+		t.toggleSaved()
+	case "o":
+		if post, ok := t.selectedPost(); ok {
+			if err := openURL(post.Url); err != nil {
+				t.message = err.Error()
+			} else {
+				t.message = "Opened " + post.Url
+			}
+		}
+	case "a":
+		t.showAll = !t.showAll
+		if err := t.loadPosts(); err != nil {
+			t.message = err.Error()
+		}
+	case "r":
+		if err := t.reload(); err != nil {
+			t.message = err.Error()
+		} else {
+			t.message = "Refreshed"
+		}
+	}
+	return true
+}
+
+func (t *tui) move(delta int) {
+	switch t.focus {
+	case tuiPaneFeeds:
+		t.feedIdx = clamp(t.feedIdx+delta, 0, len(t.feeds)-1)
+	case tuiPanePosts:
+		t.postIdx = clamp(t.postIdx+delta, 0, len(t.posts)-1)
+	case tuiPaneReader:
+		t.readerTop = clamp(t.readerTop+delta, 0, len(t.reader)-1)
+	}
+}
+
+func (t *tui) selectedPost() (*database.BrowsePostsRow, bool) {
+	if t.postIdx >= len(t.posts) {
+		return nil, false
+	}
+	return &t.posts[t.postIdx], true
+}
+
+// openReader shows the selected post in the reader pane and marks it read.
+func (t *tui) openReader() {
+	post, ok := t.selectedPost()
+	if !ok {
+		return
+	}
+	t.reader = t.reader[:0]
+	t.readerTop = 0
+	t.reader = append(t.reader, post.Title, post.FeedName+" - "+post.SortTime.Format("Mon Jan 2 15:04"))
+	if post.Author.Valid {
+		t.reader = append(t.reader, "by "+post.Author.String)
+	}
+	t.reader = append(t.reader, post.Url, "")
+	body := post.Content.String
+	if body == "" {
+		body = post.Description.String
+	}
+	t.reader = append(t.reader, wrapParagraphs(plainParagraphs(body), t.width-t.feedPaneWidth()-3)...)
+	t.focus = tuiPaneReader
+
+	if !post.Read {
+		if err := markPostRead(t.s, t.user, post.ID); err != nil {
+			t.message = err.Error()
+			return
+		}
+		post.Read = true
+		t.adjustUnread(post.FeedUrl, -1)
+	}
+}
+
+func (t *tui) toggleRead() {
+	post, ok := t.selectedPost()
+	if !ok {
+		return
+	}
+	if post.Read {
+		_, err := t.s.db.MarkPostUnread(context.Background(), database.MarkPostUnreadParams{
+			UserID: t.user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			t.message = fmt.Sprintf("couldn't mark post unread: %v", err)
+			return
+		}
+		post.Read = false
+		t.adjustUnread(post.FeedUrl, 1)
+		t.message = "Marked unread"
+		return
+	}
+	if err := markPostRead(t.s, t.user, post.ID); err != nil {
+		t.message = err.Error()
+		return
+	}
+	post.Read = true
+	t.adjustUnread(post.FeedUrl, -1)
+	t.message = "Marked read"
+}
+
+func (t *tui) toggleSaved() {
+	post, ok := t.selectedPost()
+	if !ok {
+		return
+	}
+	if post.Saved {
+		_, err := t.s.db.UnsavePost(context.Background(), database.UnsavePostParams{
+			UserID: t.user.ID,
+			PostID: post.ID,
+		})
+		if err != nil {
+			t.message = fmt.Sprintf("couldn't unsave post: %v", err)
+			return
+		}
+		post.Saved = false
+		t.message = "Removed from saved posts"
+		return
+	}
+	_, err := t.s.db.SavePost(context.Background(), database.SavePostParams{
+		UserID:    t.user.ID,
+		PostID:    post.ID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.message = fmt.Sprintf("couldn't save post: %v", err)
+		return
+	}
+	post.Saved = true
+	t.message = "Saved"
+}
+
+// adjustUnread keeps the unread counts in the feed pane in step with
+// changes made from the TUI until the next reload.
+func (t *tui) adjustUnread(feedURL string, delta int64) {
+	folder := ""
+	for i := range t.feeds {
+		if !t.feeds[i].isFolder && t.feeds[i].url == feedURL {
+			t.feeds[i].unread += delta
+			folder = t.feeds[i].folder
+		}
+	}
+	t.feeds[0].unread += delta
+	for i := range t.feeds {
+		if t.feeds[i].isFolder && t.feeds[i].folder == folder {
+			t.feeds[i].unread += delta
+		}
+	}
+}
+
+func (t *tui) feedPaneWidth() int {
+	return min(30, t.width/3)
+}
+
+// paneHeight is the number of rows in the post list; the reader pane gets
+// the rest of the right-hand side.
+func (t *tui) paneHeight() int {
+	return max(1, (t.height-2)/2)
+}
+
+func (t *tui) render() {
+	if w, h, err := terminalSize(); err == nil {
+		t.width, t.height = w, h
+	} else if t.width == 0 {
+		t.width, t.height = 80, 24
+	}
+	feedW := t.feedPaneWidth()
+	rightW := t.width - feedW - 1
+	bodyH := t.height - 2
+	listH := t.paneHeight()
+	readerH := bodyH - listH - 1
+
+	t.feedTop = scrollTop(t.feedTop, t.feedIdx, bodyH)
+	t.postTop = scrollTop(t.postTop, t.postIdx, listH)
+	t.readerTop = clamp(t.readerTop, 0, max(0, len(t.reader)-readerH))
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	mode := "unread"
+	if t.showAll {
+		mode = "all"
+	}
+	title := fmt.Sprintf(" gator - %s - %s (%s posts)", t.user.Name, t.feeds[t.feedIdx].name, mode)
+	b.WriteString("\x1b[1m" + fitWidth(title, t.width) + "\x1b[0m\r\n")
+
+	for row := 0; row < bodyH; row++ {
+		i := t.feedTop + row
+		if i < len(t.feeds) {
+			f := t.feeds[i]
+			line := " " + f.name
+			switch {
+			case f.isFolder:
+				line = " " + f.name + "/"
+			case f.folder != "":
+				line = "   " + f.name
+			}
+			if f.unread > 0 {
+				line = fmt.Sprintf("%s (%d)", line, f.unread)
+			}
+			b.WriteString(t.styled(fitWidth(line, feedW), i == t.feedIdx, t.focus == tuiPaneFeeds))
+		} else {
+			b.WriteString(strings.Repeat(" ", feedW))
+		}
+		b.WriteString("│")
+
+		switch {
+		case row < listH:
+			i := t.postTop + row
+			if i < len(t.posts) {
+				b.WriteString(t.styled(fitWidth(postListLine(t.posts[i]), rightW), i == t.postIdx, t.focus == tuiPanePosts))
+			} else {
+				b.WriteString(strings.Repeat(" ", rightW))
+			}
+		case row == listH:
+			b.WriteString(strings.Repeat("─", rightW))
+		default:
+			i := t.readerTop + row - listH - 1
+			line := ""
+			if i < len(t.reader) {
+				line = " " + t.reader[i]
+			}
+			b.WriteString(fitWidth(line, rightW))
+		}
+		b.WriteString("\r\n")
+	}
+
+	status := t.message
+	if status == "" {
+		status = "j/k move  tab/h/l pane  enter open  m read/unread  s save  o browser  a all/unread  r refresh  q quit"
+	}
+	b.WriteString("\x1b[7m" + fitWidth(" "+status, t.width) + "\x1b[0m")
+	fmt.Print(b.String())
+}
+
+// styled highlights the selected line of a pane, more strongly when the
+// pane has focus.
+func (t *tui) styled(line string, selected, focused bool) string {
+	switch {
+	case selected && focused:
+		return "\x1b[7m" + line + "\x1b[0m"
+	case selected:
+		return "\x1b[1m" + line + "\x1b[0m"
+	default:
+		return line
+	}
+}
+
+func postListLine(post database.BrowsePostsRow) string {
+	flags := []rune("  ")
+	if !post.Read {
+		flags[0] = '*'
+	}
+	if post.Saved {
+		flags[1] = '+'
+	}
+	return fmt.Sprintf("%s %s  %s - %s", string(flags), post.SortTime.Format("Jan 02"), post.Title, post.FeedName)
+}
+
+// fitWidth truncates or pads s with spaces to exactly width runes.
+func fitWidth(s string, width int) string {
+	r := []rune(s)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// scrollTop returns the first visible row of a pane of the given height so
+// that the selected row stays on screen.
+func scrollTop(top, selected, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func clamp(v, lo, hi int) int {
+	if hi < lo {
+		return lo
+	}
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+const (
+	webSubStatusPending = "pending"
+	webSubStatusActive  = "active"
+	webSubStatusDenied  = "denied"
+
+	webSubLeaseSeconds = 10 * 24 * 60 * 60
+	// Leases are renewed this long before they expire.
+	webSubRenewBefore = 24 * time.Hour
+	webSubRenewEvery  = 10 * time.Minute
+	// A subscription request the hub never verified is resent after this.
+	webSubPendingTimeout = time.Hour
+	webSubMaxBodyBytes   = 10 << 20
+)
+
+// webSubscriber subscribes to WebSub hubs advertised by feeds and serves the
+// callback endpoint hubs use to verify subscriptions and push new content.
+type webSubscriber struct {
+	db          *database.Queries
+	callbackURL string
+	client      *http.Client
+}
+
+func newWebSubscriber(db *database.Queries, callbackURL string) *webSubscriber {
+	return &webSubscriber{
+		db:          db,
+		callbackURL: strings.TrimRight(callbackURL, "/"),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (w *webSubscriber) callbackFor(feedID uuid.UUID) string {
+	return w.callbackURL + "/websub/" + feedID.String()
+}
+
+// subscribeIfAdvertised subscribes to the hub named in the fetched feed
+// unless we already hold, or are waiting on, a subscription with it.
+func (w *webSubscriber) subscribeIfAdvertised(feed database.Feed, feedData *RSSFeed) {
+	hub := feedData.atomLink("hub")
+	if hub == "" {
+		return
+	}
+	topic := feedData.atomLink("self")
+	if topic == "" {
+		topic = feed.Url
+	}
+
+	sub, err := w.db.GetWebSubSubscriptionByFeed(context.Background(), feed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		feedLogger(feed).Error("couldn't get WebSub subscription", "err", err)
+		return
+	}
+	if err == nil && sub.HubUrl == hub && sub.TopicUrl == topic {
+		switch sub.Status {
+		case webSubStatusActive, webSubStatusDenied:
+			return
+		case webSubStatusPending:
+			if time.Since(sub.UpdatedAt) < webSubPendingTimeout {
+				return
+			}
+		}
+	}
+
+	if err := w.subscribe(feed.ID, hub, topic); err != nil {
+		feedLogger(feed).Warn("couldn't subscribe to WebSub hub", "hub", hub, "err", err)
+		return
+	}
+	feedLogger(feed).Info("requested WebSub subscription", "hub", hub, "topic", topic)
+}
+
+func (w *webSubscriber) subscribe(feedID uuid.UUID, hub, topic string) error {
+	secret, err := newWebSubSecret()
+	if err != nil {
+		return err
+	}
+	// The stored secret wins over the new one when the row already exists, so
+	// renewals keep verifying pushes signed with the original secret.
+	sub, err := w.db.UpsertWebSubSubscription(context.Background(), database.UpsertWebSubSubscriptionParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		FeedID:    feedID,
+		HubUrl:    hub,
+		TopicUrl:  topic,
+		Secret:    secret,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save subscription: %w", err)
+	}
+
+	res, err := w.client.PostForm(hub, url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topic},
+		"hub.callback":      {w.callbackFor(feedID)},
+		"hub.secret":        {sub.Secret},
+		"hub.lease_seconds": {strconv.Itoa(webSubLeaseSeconds)},
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusAccepted && res.StatusCode != http.StatusNoContent {
+		return &httpStatusError{StatusCode: res.StatusCode, URL: hub}
+	}
+	return nil
+}
+
+// renewLoop resubscribes to hubs whose leases are about to run out, until
+// ctx is done. Only the leading agg instance renews, so hubs don't get
+// duplicate requests.
+func (w *webSubscriber) renewLoop(ctx context.Context, isLeader func() bool) {
+	ticker := time.NewTicker(webSubRenewEvery)
+	defer ticker.Stop()
+	for {
+		if isLeader() {
+			w.renewDue(time.Now().UTC())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *webSubscriber) renewDue(now time.Time) {
+	subs, err := w.db.GetWebSubSubscriptionsToRenew(context.Background(), sql.NullTime{
+		Time:  now.Add(webSubRenewBefore),
+		Valid: true,
+	})
+	if err != nil {
+		slog.Error("couldn't get WebSub subscriptions to renew", "err", err)
+		return
+	}
+	for _, sub := range subs {
+		if err := w.subscribe(sub.FeedID, sub.HubUrl, sub.TopicUrl); err != nil {
+			slog.Warn("couldn't renew WebSub subscription", "feed_id", sub.FeedID, "topic", sub.TopicUrl, "err", err)
+		}
+	}
+}
+
+func (w *webSubscriber) serve(addr string) {
+	slog.Info("listening for WebSub callbacks", "addr", addr)
+	if err := http.ListenAndServe(addr, w.handler()); err != nil {
+		slog.Error("WebSub callback server stopped", "err", err)
+	}
+}
+
+func (w *webSubscriber) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /websub/{feedID}", w.handleVerify)
+	mux.HandleFunc("POST /websub/{feedID}", w.handlePush)
+	return mux
+}
+
+func (w *webSubscriber) subscriptionFor(r *http.Request) (database.WebsubSubscription, bool) {
+	feedID, err := uuid.Parse(r.PathValue("feedID"))
+	if err != nil {
+		return database.WebsubSubscription{}, false
+	}
+	sub, err := w.db.GetWebSubSubscriptionByFeed(r.Context(), feedID)
+	if err != nil {
+		return database.WebsubSubscription{}, false
+	}
+	return sub, true
+}
+
+// handleVerify answers the hub's intent verification request. A subscribe
+// is only confirmed while our own request for it is pending, so a GET that
+// merely names the right topic can't activate or extend a subscription.
+func (w *webSubscriber) handleVerify(rw http.ResponseWriter, r *http.Request) {
+	sub, ok := w.subscriptionFor(r)
+	query := r.URL.Query()
+	if !ok || query.Get("hub.topic") != sub.TopicUrl {
+		http.NotFound(rw, r)
+		return
+	}
+
+	switch query.Get("hub.mode") {
+	case "subscribe":
+		if sub.Status != webSubStatusPending {
+			http.NotFound(rw, r)
+			return
+		}
+		leaseSeconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || leaseSeconds <= 0 {
+			leaseSeconds = webSubLeaseSeconds
+		}
+		_, err = w.db.ActivateWebSubSubscription(r.Context(), database.ActivateWebSubSubscriptionParams{
+			FeedID: sub.FeedID,
+			LeaseExpiresAt: sql.NullTime{
+				Time:  time.Now().UTC().Add(time.Duration(leaseSeconds) * time.Second),
+				Valid: true,
+			},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Another verification got to the pending request first.
+			http.NotFound(rw, r)
+			return
+		}
+		if err != nil {
+			slog.Error("couldn't activate WebSub subscription", "feed_id", sub.FeedID, "topic", sub.TopicUrl, "err", err)
+			http.Error(rw, "internal error", http.StatusInternalServerError)
+			return
+		}
+		slog.Info("WebSub subscription verified", "feed_id", sub.FeedID, "topic", sub.TopicUrl, "lease_seconds", leaseSeconds)
+		io.WriteString(rw, query.Get("hub.challenge"))
+	case "denied":
+		if err := w.db.DenyWebSubSubscription(r.Context(), sub.FeedID); err != nil {
+			slog.Error("couldn't mark WebSub subscription denied", "feed_id", sub.FeedID, "topic", sub.TopicUrl, "err", err)
+		}
+		slog.Warn("WebSub hub denied subscription", "feed_id", sub.FeedID, "topic", sub.TopicUrl, "reason", query.Get("hub.reason"))
+		rw.WriteHeader(http.StatusOK)
+	default:
+		// We never send unsubscribe requests, so there is never a pending one
+		// to confirm.
+		http.NotFound(rw, r)
+	}
+}
+
+// handlePush ingests content distributed by the hub through the same path
+// polled feeds take. Content is only taken for active subscriptions to feeds
+// that are still in the rotation.
+func (w *webSubscriber) handlePush(rw http.ResponseWriter, r *http.Request) {
+	sub, ok := w.subscriptionFor(r)
+	if !ok || sub.Status != webSubStatusActive {
+		http.NotFound(rw, r)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, webSubMaxBodyBytes))
+	if err != nil {
+		http.Error(rw, "couldn't read body", http.StatusBadRequest)
+		return
+	}
+	// Per the spec, a bad signature is acknowledged but the content dropped.
+	if !validWebSubSignature(sub.Secret, r.Header.Get("X-Hub-Signature"), body) {
+		slog.Warn("ignoring WebSub push with invalid signature", "feed_id", sub.FeedID, "topic", sub.TopicUrl)
+		rw.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	feed, err := w.db.GetFeedByID(r.Context(), sub.FeedID)
+	if err != nil {
+		http.NotFound(rw, r)
+		return
+	}
+	if feed.Status != feedStatusActive {
+		feedLogger(feed).Info("ignoring WebSub push for inactive feed", "status", feed.Status)
+		http.NotFound(rw, r)
+		return
+	}
+	feedData, err := parseFeed(body, feed.Url)
+	if err != nil {
+		feedLogger(feed).Warn("couldn't parse WebSub push", "err", err)
+		http.Error(rw, "couldn't parse feed", http.StatusBadRequest)
+		return
+	}
+	summary := savePosts(w.db, feed, feedData.Channel.Item)
+	feedLogger(feed).Info("feed pushed",
+		"items", len(feedData.Channel.Item),
+		"new", summary.New,
+		"updated", summary.Updated,
+		"skipped", summary.Skipped,
+	)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func validWebSubSignature(secret, header string, body []byte) bool {
+	method, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+	var newHash func() hash.Hash
+	switch method {
+	case "sha1":
+		newHash = sha1.New
+	case "sha256":
+		newHash = sha256.New
+	case "sha384":
+		newHash = sha512.New384
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func newWebSubSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sajidcodess/gator/internal/database"
+)
+
+// fakeWebSubDB answers the queries the WebSub subscriber runs, keyed on the
+// "-- name:" line sqlc puts at the start of each query, so the subscriber
+// can be tested against a hub without a PostgreSQL server.
+type fakeWebSubDB struct {
+	mu    sync.Mutex
+	feed  database.Feed
+	sub   *database.WebsubSubscription
+	posts []string
+}
+
+var sqlcQueryName = regexp.MustCompile(`^-- name: (\w+)`)
+
+var webSubColumns = []string{"id", "created_at", "updated_at", "feed_id", "hub_url", "topic_url", "secret", "status", "lease_expires_at"}
+
+func (db *fakeWebSubDB) query(query string, args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	m := sqlcQueryName.FindStringSubmatch(query)
+	if m == nil {
+		return nil, nil, fmt.Errorf("unnamed query %q", query)
+	}
+	arg := func(i int) driver.Value { return args[i].Value }
+	now := time.Now().UTC()
+
+	switch m[1] {
+	case "UpsertWebSubSubscription":
+		if db.sub == nil {
+			db.sub = &database.WebsubSubscription{
+				ID:        uuid.MustParse(arg(0).(string)),
+				CreatedAt: arg(1).(time.Time),
+				FeedID:    uuid.MustParse(arg(3).(string)),
+				Secret:    arg(6).(string),
+			}
+		}
+		db.sub.UpdatedAt = arg(2).(time.Time)
+		db.sub.HubUrl = arg(4).(string)
+		db.sub.TopicUrl = arg(5).(string)
+		db.sub.Status = webSubStatusPending
+		return db.subRows()
+	case "GetWebSubSubscriptionByFeed":
+		if db.sub == nil || db.sub.FeedID.String() != arg(0) {
+			return webSubColumns, nil, nil
+		}
+		return db.subRows()
+	case "ActivateWebSubSubscription":
+		if db.sub == nil || db.sub.FeedID.String() != arg(0) || db.sub.Status != webSubStatusPending {
+			return webSubColumns, nil, nil
+		}
+		db.sub.Status = webSubStatusActive
+		db.sub.LeaseExpiresAt = sql.NullTime{Time: arg(1).(time.Time), Valid: true}
+		db.sub.UpdatedAt = now
+		return db.subRows()
+	case "GetWebSubSubscriptionsToRenew":
+		if db.sub == nil || db.sub.Status != webSubStatusActive || !db.sub.LeaseExpiresAt.Time.Before(arg(0).(time.Time)) {
+			return webSubColumns, nil, nil
+		}
+		return db.subRows()
+	case "GetFeedByID":
+		f := db.feed
+		return []string{"id", "created_at", "updated_at", "name", "url", "user_id", "last_fetched_at", "status", "last_error", "last_status_code", "failing_since", "claimed_by", "claimed_until", "site_url", "retain_days", "retain_posts", "not_found_since"},
+			[][]driver.Value{{f.ID.String(), f.CreatedAt, f.UpdatedAt, f.Name, f.Url, f.UserID.String(), nil, f.Status, nil, nil, nil, nil, nil, nil, nil, nil, nil}}, nil
+	case "UpsertPost":
+		db.posts = append(db.posts, arg(3).(string))
+		return []string{"inserted"}, [][]driver.Value{{true}}, nil
+	case "GetRulesMatchingPost":
+		return []string{"id"}, nil, nil
+	}
+	return nil, nil, fmt.Errorf("unexpected query %s", m[1])
+}
+
+func (db *fakeWebSubDB) subRows() ([]string, [][]driver.Value, error) {
+	s := db.sub
+	var lease driver.Value
+	if s.LeaseExpiresAt.Valid {
+		lease = s.LeaseExpiresAt.Time
+	}
+	return webSubColumns, [][]driver.Value{{s.ID.String(), s.CreatedAt, s.UpdatedAt, s.FeedID.String(), s.HubUrl, s.TopicUrl, s.Secret, s.Status, lease}}, nil
+}
+
+func (db *fakeWebSubDB) subscription() database.WebsubSubscription {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return *db.sub
+}
+
+func (db *fakeWebSubDB) storedPosts() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return slices.Clone(db.posts)
+}
+
+func (db *fakeWebSubDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{db}, nil }
+func (db *fakeWebSubDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeWebSubDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	columns, rows, err := c.db.query(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, _, err := c.db.query(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// fakeHub is a WebSub hub that verifies the intent of each subscription
+// request before accepting it, as the spec requires.
+type fakeHub struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+func (h *fakeHub) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	h.requests = append(h.requests, r.PostForm)
+	h.mu.Unlock()
+	challenge := uuid.NewString()
+	status, body, err := verifyIntent(r.PostForm.Get("hub.callback"), url.Values{
+		"hub.mode":          {r.PostForm.Get("hub.mode")},
+		"hub.topic":         {r.PostForm.Get("hub.topic")},
+		"hub.challenge":     {challenge},
+		"hub.lease_seconds": {r.PostForm.Get("hub.lease_seconds")},
+	})
+	if err != nil || status != http.StatusOK || body != challenge {
+		h.t.Errorf("verification of %s failed: %d %q %v", r.PostForm.Get("hub.callback"), status, body, err)
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+func (h *fakeHub) received() []url.Values {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.requests)
+}
+
+// verifyIntent sends a hub's verification request to a callback and returns
+// the status and body of the response.
+func verifyIntent(callback string, query url.Values) (int, string, error) {
+	res, err := http.Get(callback + "?" + query.Encode())
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	return res.StatusCode, string(body), err
+}
+
+func push(t *testing.T, callback, secret, body string) int {
+	t.Helper()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req, err := http.NewRequest(http.MethodPost, callback, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/rss+xml")
+	req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func TestWebSubSubscribeVerifyPushRenew(t *testing.T) {
+	const topic = "https://example.com/feed.xml"
+	fake := &fakeWebSubDB{feed: database.Feed{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Name:      "Example",
+		Url:       topic,
+		UserID:    uuid.New(),
+		Status:    feedStatusActive,
+	}}
+	conn := sql.OpenDB(fake)
+	defer conn.Close()
+
+	hub := &fakeHub{t: t}
+	hubServer := httptest.NewServer(hub)
+	defer hubServer.Close()
+
+	w := newWebSubscriber(database.New(conn), "")
+	callbackServer := httptest.NewServer(w.handler())
+	defer callbackServer.Close()
+	w.callbackURL = callbackServer.URL
+	callback := w.callbackFor(fake.feed.ID)
+
+	// Subscribe: the hub gets our request and verifies it against the
+	// callback before accepting it.
+	if err := w.subscribe(fake.feed.ID, hubServer.URL, topic); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	requests := hub.received()
+	if len(requests) != 1 {
+		t.Fatalf("hub got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.Get("hub.mode") != "subscribe" || req.Get("hub.topic") != topic || req.Get("hub.callback") != callback {
+		t.Errorf("unexpected subscription request %v", req)
+	}
+	sub := fake.subscription()
+	if sub.Status != webSubStatusActive || !sub.LeaseExpiresAt.Valid {
+		t.Fatalf("subscription is %s with lease %v, want active with a lease", sub.Status, sub.LeaseExpiresAt)
+	}
+	if req.Get("hub.secret") != sub.Secret {
+		t.Errorf("hub was sent secret %q, stored %q", req.Get("hub.secret"), sub.Secret)
+	}
+
+	// Verify: with nothing pending, a verification naming the right topic
+	// is still refused and the lease is left alone.
+	for _, mode := range []string{"subscribe", "unsubscribe"} {
+		status, _, err := verifyIntent(callback, url.Values{
+			"hub.mode":          {mode},
+			"hub.topic":         {topic},
+			"hub.challenge":     {"forged"},
+			"hub.lease_seconds": {"999999999"},
+		})
+		if err != nil {
+			t.Fatalf("verification request failed: %v", err)
+		}
+		if status != http.StatusNotFound {
+			t.Errorf("unrequested %s verification got %d, want 404", mode, status)
+		}
+	}
+	if got := fake.subscription().LeaseExpiresAt; !got.Time.Equal(sub.LeaseExpiresAt.Time) {
+		t.Errorf("lease changed to %v by an unrequested verification", got.Time)
+	}
+
+	// Push: signed content is stored, content signed with the wrong secret
+	// is dropped.
+	rss := `<?xml version="1.0"?><rss version="2.0"><channel><title>Example</title>` +
+		`<item><title>Pushed post</title><link>https://example.com/pushed</link></item>` +
+		`</channel></rss>`
+	push(t, callback, "wrong secret", rss)
+	if posts := fake.storedPosts(); len(posts) != 0 {
+		t.Errorf("stored %v from a push with a bad signature", posts)
+	}
+	push(t, callback, sub.Secret, rss)
+	if posts := fake.storedPosts(); len(posts) != 1 || posts[0] != "Pushed post" {
+		t.Errorf("stored %v, want the pushed post", posts)
+	}
+
+	// Signed content is refused too while the feed is paused or the hub
+	// denied the subscription.
+	newer := strings.Replace(rss, "pushed", "pushed-again", 1)
+	for _, change := range []struct {
+		name string
+		set  func(feed *database.Feed, sub *database.WebsubSubscription)
+	}{
+		{"paused feed", func(feed *database.Feed, _ *database.WebsubSubscription) { feed.Status = feedStatusPaused }},
+		{"denied subscription", func(_ *database.Feed, sub *database.WebsubSubscription) { sub.Status = webSubStatusDenied }},
+	} {
+		fake.mu.Lock()
+		feed, stored := fake.feed, *fake.sub
+		change.set(&fake.feed, fake.sub)
+		fake.mu.Unlock()
+		if status := push(t, callback, sub.Secret, newer); status != http.StatusNotFound {
+			t.Errorf("push to %s got %d, want 404", change.name, status)
+		}
+		if posts := fake.storedPosts(); len(posts) != 1 {
+			t.Errorf("stored %v from a push to %s", posts, change.name)
+		}
+		fake.mu.Lock()
+		fake.feed, *fake.sub = feed, stored
+		fake.mu.Unlock()
+	}
+
+	// Renew: a lease close to running out is renewed with the same secret
+	// and verified again.
+	w.renewDue(sub.LeaseExpiresAt.Time)
+	requests = hub.received()
+	if len(requests) != 2 {
+		t.Fatalf("hub got %d requests after renewing, want 2", len(requests))
+	}
+	if got := requests[1].Get("hub.secret"); got != sub.Secret {
+		t.Errorf("renewal sent secret %q, want the original %q", got, sub.Secret)
+	}
+	renewed := fake.subscription()
+	if renewed.Status != webSubStatusActive || renewed.LeaseExpiresAt.Time.Before(sub.LeaseExpiresAt.Time) {
+		t.Errorf("renewed subscription is %s with lease %v, want active with a later lease", renewed.Status, renewed.LeaseExpiresAt.Time)
+	}
+}